@@ -1,13 +1,17 @@
 package ast
 
+import "github.com/gmisail/glamlang/io"
+
 type Type interface {
 	Equals(Type) bool
 	String() string
+	GetSpan() io.Span
 }
 
 type Node interface {
 	String() string
 	GetLine() int
+	GetSpan() io.Span
 }
 
 type Expression interface {
@@ -19,11 +23,20 @@ type Statement interface {
 	Node
 }
 
+/*
+The left-hand side of a destructuring declaration or the arm of a match
+expression, which checks the shape of a value and binds its parts to names.
+*/
+type Pattern interface {
+	Node
+}
+
 type NodeMetadata struct {
 	Line int
+	Span io.Span
 	Type Type
 }
 
-func CreateMetadata(line int) NodeMetadata {
-	return NodeMetadata{line, nil}
+func CreateMetadata(span io.Span) NodeMetadata {
+	return NodeMetadata{Line: span.Start.Line, Span: span, Type: nil}
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/gmisail/glamlang/io"
 	"github.com/gmisail/glamlang/lexer"
 )
 
@@ -28,6 +29,10 @@ func (l *Logical) GetLine() int {
 	return l.NodeMetadata.Line
 }
 
+func (l *Logical) GetSpan() io.Span {
+	return l.NodeMetadata.Span
+}
+
 func (l *Logical) GetType() Type {
 	return l.NodeMetadata.Type
 }
@@ -47,6 +52,10 @@ func (u *Unary) GetLine() int {
 	return u.NodeMetadata.Line
 }
 
+func (u *Unary) GetSpan() io.Span {
+	return u.NodeMetadata.Span
+}
+
 func (u *Unary) GetType() Type {
 	return u.NodeMetadata.Type
 }
@@ -67,10 +76,198 @@ func (b *Binary) GetLine() int {
 	return b.NodeMetadata.Line
 }
 
+func (b *Binary) GetSpan() io.Span {
+	return b.NodeMetadata.Span
+}
+
 func (b *Binary) GetType() Type {
 	return b.NodeMetadata.Type
 }
 
+/*
+Assigns a new value to a variable or record field. Operator is either EQUAL
+or one of the compound operators, i.e. ADD_EQUAL for '+='.
+*/
+type Assignment struct {
+	Expression
+	NodeMetadata
+	Target   Expression
+	Value    Expression
+	Operator lexer.TokenType
+}
+
+func (a *Assignment) String() string {
+	return fmt.Sprintf(
+		"(Assignment %s %s %s)",
+		lexer.TokenTypeToString(a.Operator),
+		a.Target.String(),
+		a.Value.String(),
+	)
+}
+
+func (a *Assignment) GetLine() int {
+	return a.NodeMetadata.Line
+}
+
+func (a *Assignment) GetSpan() io.Span {
+	return a.NodeMetadata.Span
+}
+
+func (a *Assignment) GetType() Type {
+	return a.NodeMetadata.Type
+}
+
+/*
+A range of integers from Start up to End, which is only included
+if the range is inclusive, i.e. 0..=10.
+*/
+type RangeExpression struct {
+	Expression
+	NodeMetadata
+	Start     Expression
+	End       Expression
+	Inclusive bool
+}
+
+func (r *RangeExpression) String() string {
+	return fmt.Sprintf(
+		"(Range start: %s, end: %s, inclusive: %t)",
+		r.Start.String(),
+		r.End.String(),
+		r.Inclusive,
+	)
+}
+
+func (r *RangeExpression) GetLine() int {
+	return r.NodeMetadata.Line
+}
+
+func (r *RangeExpression) GetSpan() io.Span {
+	return r.NodeMetadata.Span
+}
+
+func (r *RangeExpression) GetType() Type {
+	return r.NodeMetadata.Type
+}
+
+type ArrayLiteral struct {
+	Expression
+	NodeMetadata
+	Values []Expression
+}
+
+func (a *ArrayLiteral) String() string {
+	values := make([]string, len(a.Values))
+
+	for i, value := range a.Values {
+		values[i] = value.String()
+	}
+
+	return fmt.Sprintf("(ArrayLiteral %s)", strings.Join(values, ", "))
+}
+
+func (a *ArrayLiteral) GetLine() int {
+	return a.NodeMetadata.Line
+}
+
+func (a *ArrayLiteral) GetSpan() io.Span {
+	return a.NodeMetadata.Span
+}
+
+func (a *ArrayLiteral) GetType() Type {
+	return a.NodeMetadata.Type
+}
+
+type MapEntry struct {
+	Key   Expression
+	Value Expression
+}
+
+/*
+A map written as ["key": value], or [:] if it is empty.
+*/
+type MapLiteral struct {
+	Expression
+	NodeMetadata
+	Entries []MapEntry
+}
+
+func (m *MapLiteral) String() string {
+	entries := make([]string, len(m.Entries))
+
+	for i, entry := range m.Entries {
+		entries[i] = fmt.Sprintf("%s: %s", entry.Key.String(), entry.Value.String())
+	}
+
+	return fmt.Sprintf("(MapLiteral %s)", strings.Join(entries, ", "))
+}
+
+func (m *MapLiteral) GetLine() int {
+	return m.NodeMetadata.Line
+}
+
+func (m *MapLiteral) GetSpan() io.Span {
+	return m.NodeMetadata.Span
+}
+
+func (m *MapLiteral) GetType() Type {
+	return m.NodeMetadata.Type
+}
+
+/*
+Accesses an element of a collection, i.e. xs[i].
+*/
+type IndexExpression struct {
+	Expression
+	NodeMetadata
+	Collection Expression
+	Index      Expression
+}
+
+func (i *IndexExpression) String() string {
+	return fmt.Sprintf("(Index collection: %s, index: %s)", i.Collection.String(), i.Index.String())
+}
+
+func (i *IndexExpression) GetLine() int {
+	return i.NodeMetadata.Line
+}
+
+func (i *IndexExpression) GetSpan() io.Span {
+	return i.NodeMetadata.Span
+}
+
+func (i *IndexExpression) GetType() Type {
+	return i.NodeMetadata.Type
+}
+
+type TupleLiteral struct {
+	Expression
+	NodeMetadata
+	Values []Expression
+}
+
+func (t *TupleLiteral) String() string {
+	values := make([]string, len(t.Values))
+
+	for i, value := range t.Values {
+		values[i] = value.String()
+	}
+
+	return fmt.Sprintf("(TupleLiteral %s)", strings.Join(values, ", "))
+}
+
+func (t *TupleLiteral) GetLine() int {
+	return t.NodeMetadata.Line
+}
+
+func (t *TupleLiteral) GetSpan() io.Span {
+	return t.NodeMetadata.Span
+}
+
+func (t *TupleLiteral) GetType() Type {
+	return t.NodeMetadata.Type
+}
+
 type Group struct {
 	Expression
 	NodeMetadata
@@ -85,6 +282,10 @@ func (g *Group) GetLine() int {
 	return g.NodeMetadata.Line
 }
 
+func (g *Group) GetSpan() io.Span {
+	return g.NodeMetadata.Span
+}
+
 func (g *Group) GetType() Type {
 	return g.NodeMetadata.Type
 }
@@ -92,9 +293,10 @@ func (g *Group) GetType() Type {
 type FunctionExpression struct {
 	Expression
 	NodeMetadata
-	Parameters []VariableDeclaration
-	Body       Statement
-	ReturnType Type
+	TypeParameters []string
+	Parameters     []VariableDeclaration
+	Body           Statement
+	ReturnType     Type
 }
 
 func (f *FunctionExpression) String() string {
@@ -105,6 +307,10 @@ func (f *FunctionExpression) GetLine() int {
 	return f.NodeMetadata.Line
 }
 
+func (f *FunctionExpression) GetSpan() io.Span {
+	return f.NodeMetadata.Span
+}
+
 func (f *FunctionExpression) GetType() Type {
 	return f.NodeMetadata.Type
 }
@@ -123,16 +329,51 @@ func (v *VariableExpression) GetLine() int {
 	return v.NodeMetadata.Line
 }
 
+func (v *VariableExpression) GetSpan() io.Span {
+	return v.NodeMetadata.Span
+}
+
 func (v *VariableExpression) GetType() Type {
 	return v.NodeMetadata.Type
 }
 
+/*
+Creates a record, i.e. User { name: "bob" }. If Name refers to a variant of
+a sum type, the instance is a call to that variant's constructor.
+*/
 type RecordInstance struct {
 	Expression
 	NodeMetadata
+	Name   string
 	Values map[string]Expression
 }
 
+func (r *RecordInstance) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("(RecordInstance name: %s, values: {", r.Name))
+
+	for name, value := range r.Values {
+		builder.WriteString(fmt.Sprintf(" %s: %s", name, value.String()))
+	}
+
+	builder.WriteString(" })")
+
+	return builder.String()
+}
+
+func (r *RecordInstance) GetLine() int {
+	return r.NodeMetadata.Line
+}
+
+func (r *RecordInstance) GetSpan() io.Span {
+	return r.NodeMetadata.Span
+}
+
+func (r *RecordInstance) GetType() Type {
+	return r.NodeMetadata.Type
+}
+
 type FunctionCall struct {
 	Expression
 	NodeMetadata
@@ -162,6 +403,10 @@ func (f *FunctionCall) GetLine() int {
 	return f.NodeMetadata.Line
 }
 
+func (f *FunctionCall) GetSpan() io.Span {
+	return f.NodeMetadata.Span
+}
+
 func (f *FunctionCall) GetType() Type {
 	return f.NodeMetadata.Type
 }
@@ -181,6 +426,10 @@ func (g *GetExpression) GetLine() int {
 	return g.NodeMetadata.Line
 }
 
+func (g *GetExpression) GetSpan() io.Span {
+	return g.NodeMetadata.Span
+}
+
 func (g *GetExpression) GetType() Type {
 	return g.NodeMetadata.Type
 }
@@ -193,13 +442,128 @@ type Literal struct {
 }
 
 func (l *Literal) String() string {
-	return fmt.Sprintf("(Literal %s)", l.Value)
+	return fmt.Sprintf("(Literal %v)", l.Value)
 }
 
 func (l *Literal) GetLine() int {
 	return l.NodeMetadata.Line
 }
 
+func (l *Literal) GetSpan() io.Span {
+	return l.NodeMetadata.Span
+}
+
 func (l *Literal) GetType() Type {
 	return l.NodeMetadata.Type
 }
+
+type Interpolation struct {
+	Expression
+	NodeMetadata
+	Parts []Expression
+}
+
+func (i *Interpolation) String() string {
+	var builder strings.Builder
+
+	builder.WriteString("(Interpolation parts: [")
+
+	for j, part := range i.Parts {
+		builder.WriteString(part.String())
+
+		if j != len(i.Parts)-1 {
+			builder.WriteString(", ")
+		}
+	}
+
+	builder.WriteString("])")
+
+	return builder.String()
+}
+
+func (i *Interpolation) GetLine() int {
+	return i.NodeMetadata.Line
+}
+
+func (i *Interpolation) GetSpan() io.Span {
+	return i.NodeMetadata.Span
+}
+
+func (i *Interpolation) GetType() Type {
+	return i.NodeMetadata.Type
+}
+
+/*
+Stands in for an expression that could not be scanned or parsed. The error
+has already been reported, so it should not produce any further errors.
+*/
+type BadExpression struct {
+	Expression
+	NodeMetadata
+}
+
+func (b *BadExpression) String() string {
+	return "(BadExpression)"
+}
+
+func (b *BadExpression) GetLine() int {
+	return b.NodeMetadata.Line
+}
+
+func (b *BadExpression) GetSpan() io.Span {
+	return b.NodeMetadata.Span
+}
+
+func (b *BadExpression) GetType() Type {
+	return b.NodeMetadata.Type
+}
+
+/*
+A single case of a match expression, i.e. Circle { r } if r > 0.0 => r. The
+guard is nil if the arm doesn't have one.
+*/
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    Expression
+	Span    io.Span
+}
+
+/*
+Compares a value against the pattern of each arm in order, evaluating to
+the body of the first arm which matches.
+*/
+type MatchExpression struct {
+	Expression
+	NodeMetadata
+	Value Expression
+	Arms  []*MatchArm
+}
+
+func (m *MatchExpression) String() string {
+	arms := make([]string, len(m.Arms))
+
+	for i, arm := range m.Arms {
+		guard := ""
+
+		if arm.Guard != nil {
+			guard = fmt.Sprintf(" if %s", arm.Guard.String())
+		}
+
+		arms[i] = fmt.Sprintf("%s%s => %s", arm.Pattern.String(), guard, arm.Body.String())
+	}
+
+	return fmt.Sprintf("(MatchExpression value: %s, arms: [%s])", m.Value.String(), strings.Join(arms, ", "))
+}
+
+func (m *MatchExpression) GetLine() int {
+	return m.NodeMetadata.Line
+}
+
+func (m *MatchExpression) GetSpan() io.Span {
+	return m.NodeMetadata.Span
+}
+
+func (m *MatchExpression) GetType() Type {
+	return m.NodeMetadata.Type
+}
@@ -0,0 +1,200 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gmisail/glamlang/io"
+	"github.com/gmisail/glamlang/lexer"
+)
+
+/*
+Binds the whole value to a name.
+*/
+type BindingPattern struct {
+	Pattern
+	NodeMetadata
+	Name string
+}
+
+func (b *BindingPattern) String() string {
+	return fmt.Sprintf("(BindingPattern %s)", b.Name)
+}
+
+func (b *BindingPattern) GetLine() int {
+	return b.NodeMetadata.Line
+}
+
+func (b *BindingPattern) GetSpan() io.Span {
+	return b.NodeMetadata.Span
+}
+
+/*
+Matches each element of a tuple against a pattern, i.e. (a, b).
+*/
+type TuplePattern struct {
+	Pattern
+	NodeMetadata
+	Elements []Pattern
+}
+
+func (t *TuplePattern) String() string {
+	elements := make([]string, len(t.Elements))
+
+	for i, element := range t.Elements {
+		elements[i] = element.String()
+	}
+
+	return fmt.Sprintf("(TuplePattern %s)", strings.Join(elements, ", "))
+}
+
+func (t *TuplePattern) GetLine() int {
+	return t.NodeMetadata.Line
+}
+
+func (t *TuplePattern) GetSpan() io.Span {
+	return t.NodeMetadata.Span
+}
+
+/*
+Matches a field of a record against a pattern. In the shorthand form,
+i.e. { name }, the pattern binds the field to a variable of the same name.
+*/
+type RecordPatternField struct {
+	Name    string
+	Pattern Pattern
+	Span    io.Span
+}
+
+/*
+Matches the named fields of a record, i.e. { first, second: other }.
+*/
+type RecordPattern struct {
+	Pattern
+	NodeMetadata
+	Fields []RecordPatternField
+}
+
+func (r *RecordPattern) String() string {
+	fields := make([]string, len(r.Fields))
+
+	for i, field := range r.Fields {
+		fields[i] = fmt.Sprintf("%s: %s", field.Name, field.Pattern.String())
+	}
+
+	return fmt.Sprintf("(RecordPattern %s)", strings.Join(fields, ", "))
+}
+
+func (r *RecordPattern) GetLine() int {
+	return r.NodeMetadata.Line
+}
+
+func (r *RecordPattern) GetSpan() io.Span {
+	return r.NodeMetadata.Span
+}
+
+/*
+Matches any value without binding it, i.e. _.
+*/
+type WildcardPattern struct {
+	Pattern
+	NodeMetadata
+}
+
+func (w *WildcardPattern) String() string {
+	return "(WildcardPattern)"
+}
+
+func (w *WildcardPattern) GetLine() int {
+	return w.NodeMetadata.Line
+}
+
+func (w *WildcardPattern) GetSpan() io.Span {
+	return w.NodeMetadata.Span
+}
+
+/*
+Matches a value equal to a literal, i.e. 5, "hello" or none.
+*/
+type LiteralPattern struct {
+	Pattern
+	NodeMetadata
+	Value       interface{}
+	LiteralType lexer.TokenType
+}
+
+func (l *LiteralPattern) String() string {
+	return fmt.Sprintf("(LiteralPattern %v)", l.Value)
+}
+
+func (l *LiteralPattern) GetLine() int {
+	return l.NodeMetadata.Line
+}
+
+func (l *LiteralPattern) GetSpan() io.Span {
+	return l.NodeMetadata.Span
+}
+
+/*
+Matches the elements of an array, i.e. [first, second]. If there is a rest
+pattern, i.e. [first, ..rest], the array may have any number of elements
+after those that are listed.
+*/
+type ArrayPattern struct {
+	Pattern
+	NodeMetadata
+	Elements []Pattern
+	Rest     Pattern
+}
+
+func (a *ArrayPattern) String() string {
+	elements := make([]string, len(a.Elements))
+
+	for i, element := range a.Elements {
+		elements[i] = element.String()
+	}
+
+	if a.Rest != nil {
+		elements = append(elements, ".."+a.Rest.String())
+	}
+
+	return fmt.Sprintf("(ArrayPattern %s)", strings.Join(elements, ", "))
+}
+
+func (a *ArrayPattern) GetLine() int {
+	return a.NodeMetadata.Line
+}
+
+func (a *ArrayPattern) GetSpan() io.Span {
+	return a.NodeMetadata.Span
+}
+
+/*
+Matches a variant of a sum type along with some of its fields, i.e.
+Circle { r }. A variant without any fields can be matched by name alone,
+which is parsed as a BindingPattern and resolved by the type checker.
+*/
+type VariantPattern struct {
+	Pattern
+	NodeMetadata
+	Name   string
+	Fields []RecordPatternField
+}
+
+func (v *VariantPattern) String() string {
+	fields := make([]string, len(v.Fields))
+
+	for i, field := range v.Fields {
+		fields[i] = fmt.Sprintf("%s: %s", field.Name, field.Pattern.String())
+	}
+
+	return fmt.Sprintf("(VariantPattern %s { %s })", v.Name, strings.Join(fields, ", "))
+}
+
+func (v *VariantPattern) GetLine() int {
+	return v.NodeMetadata.Line
+}
+
+func (v *VariantPattern) GetSpan() io.Span {
+	return v.NodeMetadata.Span
+}
@@ -3,37 +3,93 @@ package ast
 import (
 	"fmt"
 	"strings"
+
+	"github.com/gmisail/glamlang/io"
 )
 
 type VariableDeclaration struct {
 	Statement
 	NodeMetadata
-	Name  string
-	Type  Type
-	Value Expression
+	Name    string
+	Type    Type
+	Value   Expression
+	Mutable bool
 }
 
 func (v *VariableDeclaration) String() string {
 	value := "nil"
+	variableType := "nil"
 
 	if v.Value != nil {
 		value = v.Value.String()
 	}
 
+	// the type is inferred if it isn't declared
+	if v.Type != nil {
+		variableType = v.Type.String()
+	}
+
 	return fmt.Sprintf(
-		"(VariableDeclaration name: %s, type: %s, value: %s)",
+		"(VariableDeclaration name: %s, type: %s, value: %s, mutable: %t)",
 		v.Name,
-		v.Type.String(),
+		variableType,
 		value,
+		v.Mutable,
+	)
+}
+
+func (v *VariableDeclaration) GetLine() int {
+	return v.NodeMetadata.Line
+}
+
+func (v *VariableDeclaration) GetSpan() io.Span {
+	return v.NodeMetadata.Span
+}
+
+/*
+Declares every name bound by the pattern, i.e. let (a, b) : (int, int) = pair.
+The type is inferred from the value if it is omitted.
+*/
+type DestructuringDeclaration struct {
+	Statement
+	NodeMetadata
+	Pattern Pattern
+	Type    Type
+	Value   Expression
+	Mutable bool
+}
+
+func (d *DestructuringDeclaration) String() string {
+	declarationType := "nil"
+
+	if d.Type != nil {
+		declarationType = d.Type.String()
+	}
+
+	return fmt.Sprintf(
+		"(DestructuringDeclaration pattern: %s, type: %s, value: %s, mutable: %t)",
+		d.Pattern.String(),
+		declarationType,
+		d.Value.String(),
+		d.Mutable,
 	)
 }
 
+func (d *DestructuringDeclaration) GetLine() int {
+	return d.NodeMetadata.Line
+}
+
+func (d *DestructuringDeclaration) GetSpan() io.Span {
+	return d.NodeMetadata.Span
+}
+
 type RecordDeclaration struct {
 	Statement
 	NodeMetadata
-	Name     string
-	Record   RecordType
-	Inherits string
+	Name           string
+	TypeParameters []string
+	Record         RecordType
+	Inherits       string
 }
 
 func (s *RecordDeclaration) String() string {
@@ -46,6 +102,45 @@ func (s *RecordDeclaration) String() string {
 	return builder.String()
 }
 
+func (s *RecordDeclaration) GetLine() int {
+	return s.NodeMetadata.Line
+}
+
+func (s *RecordDeclaration) GetSpan() io.Span {
+	return s.NodeMetadata.Span
+}
+
+/*
+Declares a sum type and its constructors, i.e. type Shape = Circle { r: float } | Empty
+*/
+type SumTypeDeclaration struct {
+	Statement
+	NodeMetadata
+	Sum *SumType
+}
+
+func (s *SumTypeDeclaration) String() string {
+	variants := make([]string, len(s.Sum.Variants))
+
+	for i, variant := range s.Sum.Variants {
+		variants[i] = fmt.Sprintf("%s %s", variant.Name, variant.Record.String())
+	}
+
+	return fmt.Sprintf(
+		"(SumTypeDeclaration name: %s, variants: %s)",
+		s.Sum.Name,
+		strings.Join(variants, " | "),
+	)
+}
+
+func (s *SumTypeDeclaration) GetLine() int {
+	return s.NodeMetadata.Line
+}
+
+func (s *SumTypeDeclaration) GetSpan() io.Span {
+	return s.NodeMetadata.Span
+}
+
 type ExpressionStatement struct {
 	Statement
 	NodeMetadata
@@ -56,6 +151,14 @@ func (e *ExpressionStatement) String() string {
 	return fmt.Sprintf("(ExpressionStatement body: %s)", e.Value.String())
 }
 
+func (e *ExpressionStatement) GetLine() int {
+	return e.NodeMetadata.Line
+}
+
+func (e *ExpressionStatement) GetSpan() io.Span {
+	return e.NodeMetadata.Span
+}
+
 type BlockStatement struct {
 	Statement
 	NodeMetadata
@@ -66,6 +169,14 @@ func (b *BlockStatement) String() string {
 	return "(BlockStatement body: )"
 }
 
+func (b *BlockStatement) GetLine() int {
+	return b.NodeMetadata.Line
+}
+
+func (b *BlockStatement) GetSpan() io.Span {
+	return b.NodeMetadata.Span
+}
+
 type IfStatement struct {
 	Statement
 	NodeMetadata
@@ -82,9 +193,18 @@ func (i *IfStatement) String() string {
 	)
 }
 
+func (i *IfStatement) GetLine() int {
+	return i.NodeMetadata.Line
+}
+
+func (i *IfStatement) GetSpan() io.Span {
+	return i.NodeMetadata.Span
+}
+
 type WhileStatement struct {
 	Statement
 	NodeMetadata
+	Label     string
 	Condition Expression
 	Body      Statement
 }
@@ -97,6 +217,88 @@ func (w *WhileStatement) String() string {
 	)
 }
 
+func (w *WhileStatement) GetLine() int {
+	return w.NodeMetadata.Line
+}
+
+func (w *WhileStatement) GetSpan() io.Span {
+	return w.NodeMetadata.Span
+}
+
+/*
+Runs the body once for every value in the iterable, i.e. a range or a
+collection, binding each value to Variable.
+*/
+type ForStatement struct {
+	Statement
+	NodeMetadata
+	Label        string
+	Variable     string
+	VariableSpan io.Span
+	Iterable     Expression
+	Body         Statement
+}
+
+func (f *ForStatement) String() string {
+	return fmt.Sprintf(
+		"(ForStatement variable: %s, iterable: %s, body: %s)",
+		f.Variable,
+		f.Iterable.String(),
+		f.Body.String(),
+	)
+}
+
+func (f *ForStatement) GetLine() int {
+	return f.NodeMetadata.Line
+}
+
+func (f *ForStatement) GetSpan() io.Span {
+	return f.NodeMetadata.Span
+}
+
+/*
+Exits the innermost loop, or the loop with the given label.
+*/
+type BreakStatement struct {
+	Statement
+	NodeMetadata
+	Label string
+}
+
+func (b *BreakStatement) String() string {
+	return fmt.Sprintf("(BreakStatement label: %s)", b.Label)
+}
+
+func (b *BreakStatement) GetLine() int {
+	return b.NodeMetadata.Line
+}
+
+func (b *BreakStatement) GetSpan() io.Span {
+	return b.NodeMetadata.Span
+}
+
+/*
+Skips to the next iteration of the innermost loop, or the loop with the
+given label.
+*/
+type ContinueStatement struct {
+	Statement
+	NodeMetadata
+	Label string
+}
+
+func (c *ContinueStatement) String() string {
+	return fmt.Sprintf("(ContinueStatement label: %s)", c.Label)
+}
+
+func (c *ContinueStatement) GetLine() int {
+	return c.NodeMetadata.Line
+}
+
+func (c *ContinueStatement) GetSpan() io.Span {
+	return c.NodeMetadata.Span
+}
+
 type ReturnStatement struct {
 	Statement
 	NodeMetadata
@@ -107,3 +309,32 @@ type ReturnStatement struct {
 func (r *ReturnStatement) String() string {
 	return fmt.Sprintf("(ReturnStatement value: %s)", r.Value.String())
 }
+
+func (r *ReturnStatement) GetLine() int {
+	return r.NodeMetadata.Line
+}
+
+func (r *ReturnStatement) GetSpan() io.Span {
+	return r.NodeMetadata.Span
+}
+
+/*
+Stands in for a statement that failed to parse, covering the tokens that
+were skipped while recovering.
+*/
+type BadStatement struct {
+	Statement
+	NodeMetadata
+}
+
+func (b *BadStatement) String() string {
+	return "(BadStatement)"
+}
+
+func (b *BadStatement) GetLine() int {
+	return b.NodeMetadata.Line
+}
+
+func (b *BadStatement) GetSpan() io.Span {
+	return b.NodeMetadata.Span
+}
@@ -2,16 +2,25 @@ package ast
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/gmisail/glamlang/io"
 	"github.com/gmisail/glamlang/lexer"
 )
 
+/*
+A named type such as int or a record. Arrays use the "array" base with the
+type of their elements as the SubType, i.e. [int]. Generic records are given
+their type arguments, i.e. Box<int>.
+*/
 type VariableType struct {
 	Type
-	Base     string
-	Optional bool
-	SubType  *VariableType
+	Base      string
+	Optional  bool
+	SubType   Type
+	Arguments []Type
+	Span      io.Span
 }
 
 func (v *VariableType) String() string {
@@ -21,13 +30,48 @@ func (v *VariableType) String() string {
 		optionalSuffix = "?"
 	}
 
+	if v.IsArray() {
+		return fmt.Sprintf("[%s]%s", v.SubType.String(), optionalSuffix)
+	}
+
+	if len(v.Arguments) > 0 {
+		return fmt.Sprintf("%s<%s>%s", v.Base, typeList(v.Arguments), optionalSuffix)
+	}
+
 	return fmt.Sprintf("%s%s", v.Base, optionalSuffix)
 }
 
+func typeList(types []Type) string {
+	names := make([]string, len(types))
+
+	for i, t := range types {
+		names[i] = t.String()
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (v *VariableType) IsArray() bool {
+	return v.Base == ArrayBase
+}
+
+/*
+The signature of a function. Generic functions list the names of their type
+parameters, i.e. <T>(T) -> T. Inferred type parameters may be limited to a
+few types, i.e. T in <T: float | int>(T) -> T, which is kept in Constraints.
+*/
 type FunctionType struct {
 	Type
-	Parameters []Type
-	ReturnType Type
+	TypeParameters []string
+	Constraints    map[string][]string
+	Parameters     []Type
+	ReturnType     Type
+	Optional       bool
+	Span           io.Span
+}
+
+func (v *VariableType) GetSpan() io.Span {
+	return v.Span
 }
 
 func (f *FunctionType) String() string {
@@ -41,12 +85,41 @@ func (f *FunctionType) String() string {
 		}
 	}
 
-	return fmt.Sprintf("(%s) -> %s", builder.String(), f.ReturnType.String())
+	typeParameters := ""
+
+	if len(f.TypeParameters) > 0 {
+		names := make([]string, len(f.TypeParameters))
+
+		for i, name := range f.TypeParameters {
+			names[i] = name
+
+			if constraint, isConstrained := f.Constraints[name]; isConstrained {
+				names[i] = fmt.Sprintf("%s: %s", name, strings.Join(constraint, " | "))
+			}
+		}
+
+		typeParameters = fmt.Sprintf("<%s>", strings.Join(names, ", "))
+	}
+
+	signature := fmt.Sprintf("%s(%s) -> %s", typeParameters, builder.String(), f.ReturnType.String())
+
+	if f.Optional {
+		return fmt.Sprintf("(%s)?", signature)
+	}
+
+	return signature
 }
 
 type RecordType struct {
 	Type
-	Fields map[string]Type
+	TypeParameters []string
+	Fields         map[string]Type
+	Optional       bool
+	Span           io.Span
+}
+
+func (f *FunctionType) GetSpan() io.Span {
+	return f.Span
 }
 
 func (r *RecordType) String() string {
@@ -60,9 +133,166 @@ func (r *RecordType) String() string {
 
 	builder.WriteString("}")
 
+	if r.Optional {
+		builder.WriteString("?")
+	}
+
 	return builder.String()
 }
 
+func (r *RecordType) GetSpan() io.Span {
+	return r.Span
+}
+
+const ArrayBase = "array"
+
+/*
+A collection of values indexed by keys, written as {string: int}.
+*/
+type MapType struct {
+	Type
+	Key      Type
+	Value    Type
+	Optional bool
+	Span     io.Span
+}
+
+func (m *MapType) String() string {
+	optionalSuffix := ""
+
+	if m.Optional {
+		optionalSuffix = "?"
+	}
+
+	return fmt.Sprintf("{%s: %s}%s", m.Key.String(), m.Value.String(), optionalSuffix)
+}
+
+func (m *MapType) GetSpan() io.Span {
+	return m.Span
+}
+
+/*
+A fixed number of values which may have different types, i.e. (int, string).
+*/
+type TupleType struct {
+	Type
+	Elements []Type
+	Optional bool
+	Span     io.Span
+}
+
+func (t *TupleType) String() string {
+	elements := make([]string, len(t.Elements))
+
+	for i, element := range t.Elements {
+		elements[i] = element.String()
+	}
+
+	optionalSuffix := ""
+
+	if t.Optional {
+		optionalSuffix = "?"
+	}
+
+	return fmt.Sprintf("(%s)%s", strings.Join(elements, ", "), optionalSuffix)
+}
+
+func (t *TupleType) GetSpan() io.Span {
+	return t.Span
+}
+
+/*
+A single case of a sum type along with the fields it carries. Variants
+without any fields have an empty record.
+*/
+type Variant struct {
+	Name   string
+	Record RecordType
+	Span   io.Span
+}
+
+/*
+A tagged union of variants, i.e. Circle { r: float } | Empty. Sum types are
+nominal, so two sum types are only equal if they share a name.
+*/
+type SumType struct {
+	Type
+	Name     string
+	Variants []*Variant
+	Optional bool
+	Span     io.Span
+}
+
+func (s *SumType) String() string {
+	if s.Optional {
+		return fmt.Sprintf("%s?", s.Name)
+	}
+
+	return s.Name
+}
+
+func (s *SumType) GetSpan() io.Span {
+	return s.Span
+}
+
+/*
+Looks up a variant by name, returning nil if it is not part of the sum type.
+*/
+func (s *SumType) FindVariant(name string) *Variant {
+	for _, variant := range s.Variants {
+		if variant.Name == name {
+			return variant
+		}
+	}
+
+	return nil
+}
+
+/*
+A type which hasn't been inferred yet, i.e. the type of x in fn(x) => x. Once
+it is unified with another type, Instance points to that type. Optional type
+variables wrap another type variable, i.e. T? where T hasn't been inferred.
+A constrained type variable can only be inferred to be one of the types in
+its Constraint, i.e. the operands of - must be an int or a float.
+*/
+type TypeVariable struct {
+	Type
+	ID         int
+	Level      int
+	Optional   bool
+	Constraint []string
+	Instance   Type
+}
+
+func (t *TypeVariable) String() string {
+	if pruned := Prune(t); pruned != t {
+		return pruned.String()
+	}
+
+	if t.Optional {
+		return fmt.Sprintf("%s?", t.Instance.String())
+	}
+
+	// the type hasn't been inferred yet, which is shown the same way as a wildcard
+	return "_"
+}
+
+func (t *TypeVariable) GetSpan() io.Span {
+	return io.Span{}
+}
+
+/*
+Returns the type variable at the root of an optional type variable, or the
+type variable itself if it isn't optional.
+*/
+func (t *TypeVariable) Root() *TypeVariable {
+	if t.Optional {
+		return t.Instance.(*TypeVariable).Root()
+	}
+
+	return t
+}
+
 var internalTypes = map[string]Type{
 	"int":    &VariableType{Base: "int", Optional: false},
 	"float":  &VariableType{Base: "float", Optional: false},
@@ -85,15 +315,38 @@ func (v *VariableType) Equals(otherType Type) bool {
 		(int, int) -> int == (int, int) -> int   (yes)
 	*/
 
-	switch target := otherType.(type) {
+	switch target := Prune(otherType).(type) {
 	case *VariableType:
 		if v.Base != target.Base || v.Optional != target.Optional {
 			return false
 		}
+
+		if v.IsArray() {
+			return v.SubType.Equals(target.SubType)
+		}
+
+		if len(v.Arguments) != len(target.Arguments) {
+			return false
+		}
+
+		for i, argument := range v.Arguments {
+			if !argument.Equals(target.Arguments[i]) {
+				return false
+			}
+		}
 	case *FunctionType:
 		return false
 	case *RecordType:
 		return false
+	case *MapType:
+		return false
+	case *TupleType:
+		return false
+	case *SumType:
+		// annotations refer to sum types by name, i.e. let s: Shape = Empty
+		return v.Optional == target.Optional && !v.IsArray() && v.Base == target.Name
+	case *TypeVariable:
+		return false
 	}
 
 	return true
@@ -108,15 +361,34 @@ func (f *FunctionType) Equals(otherType Type) bool {
 		If the otherType is a variable type (i.e. int, string, etc...)
 		automatically reject it since a function signature =/= variable type.
 	*/
-	switch target := otherType.(type) {
+	switch target := Prune(otherType).(type) {
 	case *VariableType:
 		return false
 	case *FunctionType:
+		if f.Optional != target.Optional {
+			return false
+		}
+
 		// validate length of parameters
-		if len(target.Parameters) != len(f.Parameters) {
+		if len(target.Parameters) != len(f.Parameters) || len(target.TypeParameters) != len(f.TypeParameters) {
 			return false
 		}
 
+		// the names of type parameters don't matter, i.e. <T>(T) -> T == <U>(U) -> U
+		if len(f.TypeParameters) > 0 {
+			renamed := make(map[string]Type)
+
+			for i, name := range target.TypeParameters {
+				renamed[name] = CreateVariableType(f.TypeParameters[i], false)
+
+				if strings.Join(f.Constraints[f.TypeParameters[i]], "|") != strings.Join(target.Constraints[name], "|") {
+					return false
+				}
+			}
+
+			target = Substitute(CreateFunctionType(target.Parameters, target.ReturnType), renamed).(*FunctionType)
+		}
+
 		// validate that every parameter matches
 		for i, param := range f.Parameters {
 			if !param.Equals(target.Parameters[i]) {
@@ -129,6 +401,14 @@ func (f *FunctionType) Equals(otherType Type) bool {
 		}
 	case *RecordType:
 		return false
+	case *MapType:
+		return false
+	case *TupleType:
+		return false
+	case *SumType:
+		return false
+	case *TypeVariable:
+		return false
 	}
 
 	return true
@@ -139,12 +419,16 @@ func (r *RecordType) Equals(otherType Type) bool {
 		return false
 	}
 
-	switch target := otherType.(type) {
+	switch target := Prune(otherType).(type) {
 	case *VariableType:
 		return false
 	case *FunctionType:
 		return false
 	case *RecordType:
+		if r.Optional != target.Optional {
+			return false
+		}
+
 		/*
 			Ensure that every property in the calling Record is
 			available in the target Record.
@@ -161,15 +445,132 @@ func (r *RecordType) Equals(otherType Type) bool {
 		}
 
 		return true
+	case *MapType:
+		return false
+	case *TupleType:
+		return false
+	case *SumType:
+		return false
+	case *TypeVariable:
+		return false
 	}
 
 	return true
 }
 
+func (m *MapType) Equals(otherType Type) bool {
+	target, isMap := Prune(otherType).(*MapType)
+
+	if !isMap || m.Optional != target.Optional {
+		return false
+	}
+
+	return m.Key.Equals(target.Key) && m.Value.Equals(target.Value)
+}
+
+func (t *TupleType) Equals(otherType Type) bool {
+	target, isTuple := Prune(otherType).(*TupleType)
+
+	if !isTuple || t.Optional != target.Optional || len(t.Elements) != len(target.Elements) {
+		return false
+	}
+
+	for i, element := range t.Elements {
+		if !element.Equals(target.Elements[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *SumType) Equals(otherType Type) bool {
+	switch target := Prune(otherType).(type) {
+	case *SumType:
+		return s.Name == target.Name && s.Optional == target.Optional
+	case *VariableType:
+		return target.Equals(s)
+	}
+
+	return false
+}
+
+/*
+Type variables are only equal to the type they have been unified with, or to
+themselves if they haven't been unified yet.
+*/
+func (t *TypeVariable) Equals(otherType Type) bool {
+	if pruned := Prune(t); pruned != t {
+		return pruned.Equals(otherType)
+	}
+
+	target, isVariable := Prune(otherType).(*TypeVariable)
+
+	return isVariable && target.Optional == t.Optional && target.Root() == t.Root()
+}
+
 func CreateVariableType(name string, isOptional bool) *VariableType {
 	return &VariableType{Base: name, Optional: isOptional}
 }
 
+func CreateArrayType(elementType Type) *VariableType {
+	return &VariableType{Base: ArrayBase, SubType: elementType}
+}
+
+func CreateMapType(keyType Type, valueType Type) *MapType {
+	return &MapType{Key: keyType, Value: valueType}
+}
+
+func CreateTupleType(elements []Type) *TupleType {
+	return &TupleType{Elements: elements}
+}
+
+/*
+Returns an optional version of the type, i.e. int becomes int?.
+*/
+func MakeOptional(t Type) Type {
+	switch targetType := Prune(t).(type) {
+	case *VariableType:
+		optionalType := *targetType
+		optionalType.Optional = true
+
+		return &optionalType
+	case *FunctionType:
+		optionalType := *targetType
+		optionalType.Optional = true
+
+		return &optionalType
+	case *RecordType:
+		optionalType := *targetType
+		optionalType.Optional = true
+
+		return &optionalType
+	case *MapType:
+		optionalType := *targetType
+		optionalType.Optional = true
+
+		return &optionalType
+	case *TupleType:
+		optionalType := *targetType
+		optionalType.Optional = true
+
+		return &optionalType
+	case *SumType:
+		optionalType := *targetType
+		optionalType.Optional = true
+
+		return &optionalType
+	case *TypeVariable:
+		if targetType.Optional {
+			return targetType
+		}
+
+		return &TypeVariable{Optional: true, Instance: targetType}
+	}
+
+	return t
+}
+
 func CreateFunctionType(parameters []Type, returnType Type) *FunctionType {
 	return &FunctionType{Parameters: parameters, ReturnType: returnType}
 }
@@ -187,7 +588,17 @@ func CreateTypeFromLiteral(literalType lexer.TokenType) Type {
 func CreateTypeFrom(t Type) Type {
 	switch targetType := t.(type) {
 	case *VariableType:
-		return CreateVariableType(targetType.Base, targetType.Optional)
+		variableType := CreateVariableType(targetType.Base, targetType.Optional)
+
+		if targetType.SubType != nil {
+			variableType.SubType = CreateTypeFrom(targetType.SubType)
+		}
+
+		for _, argument := range targetType.Arguments {
+			variableType.Arguments = append(variableType.Arguments, CreateTypeFrom(argument))
+		}
+
+		return variableType
 	case *FunctionType:
 		parameters := make([]Type, len(targetType.Parameters))
 
@@ -195,12 +606,301 @@ func CreateTypeFrom(t Type) Type {
 			parameters[i] = CreateTypeFrom(param)
 		}
 
-		return CreateFunctionType(parameters, CreateTypeFrom(targetType.ReturnType))
+		functionType := CreateFunctionType(parameters, CreateTypeFrom(targetType.ReturnType))
+		functionType.TypeParameters = targetType.TypeParameters
+		functionType.Constraints = targetType.Constraints
+		functionType.Optional = targetType.Optional
+
+		return functionType
+	case *MapType:
+		mapType := CreateMapType(CreateTypeFrom(targetType.Key), CreateTypeFrom(targetType.Value))
+		mapType.Optional = targetType.Optional
+
+		return mapType
+	case *TupleType:
+		elements := make([]Type, len(targetType.Elements))
+
+		for i, element := range targetType.Elements {
+			elements[i] = CreateTypeFrom(element)
+		}
+
+		tupleType := CreateTupleType(elements)
+		tupleType.Optional = targetType.Optional
+
+		return tupleType
+	case *SumType:
+		// sum types are nominal, so the declaration can be shared
+		return targetType
+	case *TypeVariable:
+		if pruned := Prune(targetType); pruned != targetType {
+			return CreateTypeFrom(pruned)
+		}
+
+		// copying a type variable would stop it from being inferred with the original
+		return targetType
 	}
 
 	return nil
 }
 
+/*
+Replaces every type parameter with the type it is bound to, i.e. Box<T>
+becomes Box<int> if T is bound to int. Type parameters of generic functions
+shadow those with the same name.
+*/
+func Substitute(t Type, bindings map[string]Type) Type {
+	switch targetType := t.(type) {
+	case *VariableType:
+		if bound, isBound := bindings[targetType.Base]; isBound && targetType.SubType == nil && len(targetType.Arguments) == 0 {
+			if targetType.Optional {
+				return MakeOptional(bound)
+			}
+
+			return bound
+		}
+
+		substituted := *targetType
+
+		if targetType.SubType != nil {
+			substituted.SubType = Substitute(targetType.SubType, bindings)
+		}
+
+		substituted.Arguments = substituteAll(targetType.Arguments, bindings)
+
+		return &substituted
+	case *FunctionType:
+		if len(targetType.TypeParameters) > 0 {
+			shadowed := make(map[string]Type)
+
+			for name, bound := range bindings {
+				shadowed[name] = bound
+			}
+
+			for _, name := range targetType.TypeParameters {
+				delete(shadowed, name)
+			}
+
+			bindings = shadowed
+		}
+
+		functionType := CreateFunctionType(
+			substituteAll(targetType.Parameters, bindings),
+			Substitute(targetType.ReturnType, bindings),
+		)
+		functionType.TypeParameters = targetType.TypeParameters
+		functionType.Constraints = targetType.Constraints
+		functionType.Optional = targetType.Optional
+		functionType.Span = targetType.Span
+
+		return functionType
+	case *RecordType:
+		fields := make(map[string]Type)
+
+		for name, field := range targetType.Fields {
+			fields[name] = Substitute(field, bindings)
+		}
+
+		return &RecordType{Fields: fields, Optional: targetType.Optional, Span: targetType.Span}
+	case *MapType:
+		mapType := CreateMapType(Substitute(targetType.Key, bindings), Substitute(targetType.Value, bindings))
+		mapType.Optional = targetType.Optional
+		mapType.Span = targetType.Span
+
+		return mapType
+	case *TupleType:
+		tupleType := CreateTupleType(substituteAll(targetType.Elements, bindings))
+		tupleType.Optional = targetType.Optional
+		tupleType.Span = targetType.Span
+
+		return tupleType
+	case *TypeVariable:
+		if pruned := Prune(targetType); pruned != targetType {
+			return Substitute(pruned, bindings)
+		}
+	}
+
+	return t
+}
+
+func substituteAll(types []Type, bindings map[string]Type) []Type {
+	if types == nil {
+		return nil
+	}
+
+	substituted := make([]Type, len(types))
+
+	for i, t := range types {
+		substituted[i] = Substitute(t, bindings)
+	}
+
+	return substituted
+}
+
+/*
+Follows type variables to the type they have been unified with. Types which
+aren't type variables, or haven't been unified, are returned as they are.
+*/
+func Prune(t Type) Type {
+	variable, isVariable := t.(*TypeVariable)
+
+	if !isVariable || variable.Instance == nil {
+		return t
+	}
+
+	if variable.Optional {
+		inner := Prune(variable.Instance)
+
+		if innerVariable, isInnerVariable := inner.(*TypeVariable); isInnerVariable {
+			if innerVariable.Optional {
+				return innerVariable
+			}
+
+			variable.Instance = innerVariable
+
+			return variable
+		}
+
+		return MakeOptional(inner)
+	}
+
+	variable.Instance = Prune(variable.Instance)
+
+	return variable.Instance
+}
+
+/*
+Replaces every type variable which has been unified with the type it was
+unified with, i.e. ('a) -> 'a becomes (int) -> int once 'a is inferred.
+*/
+func Resolve(t Type) Type {
+	switch targetType := Prune(t).(type) {
+	case *VariableType:
+		if targetType.SubType == nil && len(targetType.Arguments) == 0 {
+			return targetType
+		}
+
+		resolved := *targetType
+
+		if targetType.SubType != nil {
+			resolved.SubType = Resolve(targetType.SubType)
+		}
+
+		resolved.Arguments = resolveAll(targetType.Arguments)
+
+		return &resolved
+	case *FunctionType:
+		functionType := CreateFunctionType(resolveAll(targetType.Parameters), Resolve(targetType.ReturnType))
+		functionType.TypeParameters = targetType.TypeParameters
+		functionType.Constraints = targetType.Constraints
+		functionType.Optional = targetType.Optional
+		functionType.Span = targetType.Span
+
+		return functionType
+	case *RecordType:
+		fields := make(map[string]Type)
+
+		for name, field := range targetType.Fields {
+			fields[name] = Resolve(field)
+		}
+
+		return &RecordType{
+			TypeParameters: targetType.TypeParameters,
+			Fields:         fields,
+			Optional:       targetType.Optional,
+			Span:           targetType.Span,
+		}
+	case *MapType:
+		mapType := CreateMapType(Resolve(targetType.Key), Resolve(targetType.Value))
+		mapType.Optional = targetType.Optional
+		mapType.Span = targetType.Span
+
+		return mapType
+	case *TupleType:
+		tupleType := CreateTupleType(resolveAll(targetType.Elements))
+		tupleType.Optional = targetType.Optional
+		tupleType.Span = targetType.Span
+
+		return tupleType
+	default:
+		return targetType
+	}
+}
+
+func resolveAll(types []Type) []Type {
+	if types == nil {
+		return nil
+	}
+
+	resolved := make([]Type, len(types))
+
+	for i, t := range types {
+		resolved[i] = Resolve(t)
+	}
+
+	return resolved
+}
+
+/*
+Returns every type variable within the type which hasn't been unified yet, in
+the order they first appear.
+*/
+func FreeVariables(t Type) []*TypeVariable {
+	variables := make([]*TypeVariable, 0)
+	seen := make(map[*TypeVariable]bool)
+
+	var visit func(Type)
+
+	visit = func(t Type) {
+		switch targetType := Prune(t).(type) {
+		case *TypeVariable:
+			root := targetType.Root()
+
+			if !seen[root] {
+				seen[root] = true
+				variables = append(variables, root)
+			}
+		case *VariableType:
+			if targetType.SubType != nil {
+				visit(targetType.SubType)
+			}
+
+			for _, argument := range targetType.Arguments {
+				visit(argument)
+			}
+		case *FunctionType:
+			for _, param := range targetType.Parameters {
+				visit(param)
+			}
+
+			visit(targetType.ReturnType)
+		case *RecordType:
+			names := make([]string, 0, len(targetType.Fields))
+
+			for name := range targetType.Fields {
+				names = append(names, name)
+			}
+
+			// fields are visited by name so that the order doesn't change between runs
+			sort.Strings(names)
+
+			for _, name := range names {
+				visit(targetType.Fields[name])
+			}
+		case *MapType:
+			visit(targetType.Key)
+			visit(targetType.Value)
+		case *TupleType:
+			for _, element := range targetType.Elements {
+				visit(element)
+			}
+		}
+	}
+
+	visit(t)
+
+	return variables
+}
+
 func IsInternalType(target Type) (bool, Type) {
 	if targetType, ok := target.(*VariableType); ok {
 		//isOptional := targetType.Optional
@@ -16,20 +16,27 @@ func CreateContext() *Context {
 Looks up the type of a variable if it exists.
 */
 func (c *Context) FindVariable(name string) (bool, *ast.Type) {
-	isValid, variableType := c.environment.FindVariable(name)
+	isValid, binding := c.environment.FindVariable(name)
 
 	if isValid {
-		return isValid, variableType
+		return isValid, binding.Type
 	}
 
 	return false, nil
 }
 
+/*
+Looks up the binding of a variable, i.e. to check if it is mutable.
+*/
+func (c *Context) FindBinding(name string) (bool, *Binding) {
+	return c.environment.FindVariable(name)
+}
+
 /*
 Adds variable to the current scope.
 */
-func (c *Context) Add(variableName string, variableType *ast.Type) bool {
-	return c.environment.AddVariable(variableName, variableType)
+func (c *Context) Add(variableName string, binding *Binding) bool {
+	return c.environment.AddVariable(variableName, binding)
 }
 
 func (c *Context) AddType(typeName string, recordType ast.RecordType) bool {
@@ -40,6 +47,26 @@ func (c *Context) FindType(typeName string) (bool, *ast.RecordType) {
 	return c.environment.FindType(typeName)
 }
 
+func (c *Context) AddSumType(typeName string, sum *ast.SumType) bool {
+	return c.environment.AddSumType(typeName, sum)
+}
+
+func (c *Context) FindSumType(typeName string) (bool, *ast.SumType) {
+	return c.environment.FindSumType(typeName)
+}
+
+func (c *Context) AddConstructor(variantName string, sum *ast.SumType) bool {
+	return c.environment.AddConstructor(variantName, sum)
+}
+
+func (c *Context) FindConstructor(variantName string) (bool, *ast.SumType) {
+	return c.environment.FindConstructor(variantName)
+}
+
+func (c *Context) AddTypeParameter(name string) bool {
+	return c.environment.AddTypeParameter(name)
+}
+
 func (c *Context) TypeExists(typeName string) bool {
 	return c.environment.TypeExists(typeName)
 }
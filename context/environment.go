@@ -2,29 +2,71 @@ package context
 
 import (
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/io"
 )
 
+/*
+What introduced a binding, which decides how it can be made mutable.
+*/
+type BindingKind int
+
+const (
+	// let x = ..., including the names in a destructuring pattern
+	VariableBinding BindingKind = iota
+	// fn(x) => ...
+	ParameterBinding
+	// for (x in xs) ...
+	LoopBinding
+	// match value { x => ... }
+	MatchBinding
+)
+
+/*
+A variable in scope, whether or not it can be reassigned, and
+where it was declared. Variables whose declaration failed to type
+check are invalid, since their type may only be partially inferred.
+*/
+type Binding struct {
+	Type    *ast.Type
+	Mutable bool
+	Kind    BindingKind
+	Invalid bool
+	Span    io.Span
+}
+
+func CreateBinding(variableType *ast.Type, mutable bool, kind BindingKind, span io.Span) *Binding {
+	return &Binding{Type: variableType, Mutable: mutable, Kind: kind, Span: span}
+}
+
 type Environment struct {
-	Parent *Environment
-	Values map[string]*ast.Type
-	Types  map[string]ast.RecordType
+	Parent       *Environment
+	Values       map[string]*Binding
+	Types        map[string]ast.RecordType
+	Sums         map[string]*ast.SumType
+	Constructors map[string]*ast.SumType
+
+	// type parameters of the generic records and functions being checked
+	Parameters map[string]bool
 }
 
 func CreateEnvironment(parent *Environment) *Environment {
 	return &Environment{
-		Parent: parent,
-		Values: make(map[string]*ast.Type),
-		Types:  make(map[string]ast.RecordType),
+		Parent:       parent,
+		Values:       make(map[string]*Binding),
+		Types:        make(map[string]ast.RecordType),
+		Sums:         make(map[string]*ast.SumType),
+		Constructors: make(map[string]*ast.SumType),
+		Parameters:   make(map[string]bool),
 	}
 }
 
 /*
-Looks up the type of a variable if it exists.
+Looks up the binding of a variable if it exists.
 */
-func (e *Environment) FindVariable(name string) (bool, *ast.Type) {
+func (e *Environment) FindVariable(name string) (bool, *Binding) {
 	// check the current scope
-	if variableType, ok := e.Values[name]; ok {
-		return true, variableType
+	if binding, ok := e.Values[name]; ok {
+		return true, binding
 	}
 
 	if e.Parent == nil {
@@ -38,22 +80,30 @@ func (e *Environment) FindVariable(name string) (bool, *ast.Type) {
 Adds a variable to the context. Returns false
 if the variable already exists in the current scope.
 */
-func (e *Environment) AddVariable(variableName string, variableType *ast.Type) bool {
+func (e *Environment) AddVariable(variableName string, binding *Binding) bool {
 	exists, _ := e.FindVariable(variableName)
 
 	if exists {
 		return false
 	}
 
-	e.Values[variableName] = variableType
+	e.Values[variableName] = binding
 
 	return true
 }
 
 /*
-Returns if a custom type exists in the current context.
+Returns if a custom type, either a record or a sum type, exists in the current context.
 */
 func (e *Environment) TypeExists(typeName string) bool {
+	if exists, _ := e.FindSumType(typeName); exists {
+		return true
+	}
+
+	if e.IsTypeParameter(typeName) {
+		return true
+	}
+
 	exists, _ := e.FindType(typeName)
 	return exists
 }
@@ -84,3 +134,83 @@ func (e *Environment) FindType(typeName string) (bool, *ast.RecordType) {
 
 	return e.Parent.FindType(typeName)
 }
+
+/*
+Adds a sum type if no other type with the same name exists.
+*/
+func (e *Environment) AddSumType(typeName string, sum *ast.SumType) bool {
+	if e.TypeExists(typeName) {
+		return false
+	}
+
+	e.Sums[typeName] = sum
+
+	return true
+}
+
+func (e *Environment) FindSumType(typeName string) (bool, *ast.SumType) {
+	if sum, ok := e.Sums[typeName]; ok {
+		return true, sum
+	}
+
+	if e.Parent == nil {
+		return false, nil
+	}
+
+	return e.Parent.FindSumType(typeName)
+}
+
+/*
+Registers the constructor of a variant, returning false if a constructor
+with the same name already exists.
+*/
+func (e *Environment) AddConstructor(variantName string, sum *ast.SumType) bool {
+	if exists, _ := e.FindConstructor(variantName); exists {
+		return false
+	}
+
+	e.Constructors[variantName] = sum
+
+	return true
+}
+
+/*
+Looks up the sum type which a variant's constructor belongs to.
+*/
+func (e *Environment) FindConstructor(variantName string) (bool, *ast.SumType) {
+	if sum, ok := e.Constructors[variantName]; ok {
+		return true, sum
+	}
+
+	if e.Parent == nil {
+		return false, nil
+	}
+
+	return e.Parent.FindConstructor(variantName)
+}
+
+/*
+Adds a type parameter to the current scope, returning false if it has
+the same name as another type.
+*/
+func (e *Environment) AddTypeParameter(name string) bool {
+	if e.TypeExists(name) {
+		return false
+	}
+
+	e.Parameters[name] = true
+
+	return true
+}
+
+func (e *Environment) IsTypeParameter(name string) bool {
+	if e.Parameters[name] {
+		return true
+	}
+
+	if e.Parent == nil {
+		return false
+	}
+
+	return e.Parent.IsTypeParameter(name)
+}
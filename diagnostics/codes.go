@@ -0,0 +1,58 @@
+package diagnostics
+
+// codes identify the kind of problem, so that tools can recognize it without
+// parsing the message. The first letter is the stage which reports it.
+const (
+	// a character which can't begin any token
+	InvalidCharacterCode = "L0001"
+	// a malformed or out of range number literal
+	InvalidNumberCode = "L0002"
+	// a string, comment or interpolation which is never closed
+	UnterminatedCode = "L0003"
+	// an unknown or malformed escape sequence within a string
+	InvalidEscapeCode = "L0004"
+)
+
+const (
+	// a token which can't appear where it was found
+	UnexpectedTokenCode = "P0001"
+	// input which ends in the middle of a statement
+	UnexpectedEndCode = "P0002"
+	// valid tokens arranged in a way the language doesn't allow, i.e. a labeled if
+	InvalidSyntaxCode = "P0003"
+)
+
+const (
+	// a value whose type differs from the one that is expected
+	MismatchedTypesCode = "T0001"
+	// a variable or type which doesn't exist
+	UndefinedNameCode = "T0002"
+	// a field which doesn't exist or can't be accessed
+	InvalidFieldCode = "T0003"
+	// a name which is declared more than once
+	DuplicateNameCode = "T0004"
+	// an assignment to an immutable variable or to something that can't be assigned to
+	InvalidAssignmentCode = "T0005"
+	// an operator, index or loop applied to a type which doesn't support it
+	InvalidOperationCode = "T0006"
+	// a call to a value which isn't a function, or with the wrong arguments
+	InvalidCallCode = "T0007"
+	// a pattern which can never match the value's type
+	InvalidPatternCode = "T0008"
+	// a match or destructuring pattern which doesn't cover every value
+	NonExhaustiveCode = "T0009"
+	// a match arm which is never reached
+	UnreachablePatternCode = "T0010"
+	// a value whose type can't be inferred
+	AmbiguousTypeCode = "T0011"
+	// a function body which doesn't return a value
+	MissingReturnCode = "T0012"
+	// a break or continue outside of a loop, or with an unknown label
+	InvalidJumpCode = "T0013"
+	// a generic type given the wrong number of type arguments
+	TypeArgumentsCode = "T0014"
+	// a map key whose type can't be hashed
+	UnhashableKeyCode = "T0015"
+	// a declaration which isn't allowed, i.e. redeclaring a built-in type
+	InvalidDeclarationCode = "T0016"
+)
@@ -0,0 +1,100 @@
+package diagnostics
+
+import (
+	"github.com/gmisail/glamlang/io"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	}
+
+	return "?"
+}
+
+/*
+Points at a span of source code along with a short explanation, i.e.
+"declared here".
+*/
+type Label struct {
+	Span    io.Span
+	Message string
+}
+
+/*
+A message about a span of source code. The primary label is underlined
+with carets while any secondary labels are underlined with dashes. Notes
+are printed after the source snippet.
+*/
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Message  string
+	Primary  Label
+	Labels   []Label
+	Notes    []string
+}
+
+/*
+Implemented by every error (lexer, parser and type checker) that can be
+rendered as a diagnostic.
+*/
+type Reporter interface {
+	error
+	Diagnostic() *Diagnostic
+}
+
+func CreateDiagnostic(severity Severity, code string, message string, span io.Span) *Diagnostic {
+	return &Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  message,
+		Primary:  Label{Span: span},
+		Labels:   make([]Label, 0),
+		Notes:    make([]string, 0),
+	}
+}
+
+/*
+Adds a secondary label to the diagnostic.
+*/
+func (d *Diagnostic) WithLabel(span io.Span, message string) *Diagnostic {
+	d.Labels = append(d.Labels, Label{Span: span, Message: message})
+
+	return d
+}
+
+/*
+Adds a note, i.e. a hint on how to fix the problem, to the diagnostic.
+*/
+func (d *Diagnostic) WithNote(note string) *Diagnostic {
+	d.Notes = append(d.Notes, note)
+
+	return d
+}
+
+/*
+Returns true if any of the diagnostics is an error.
+*/
+func HasErrors(diagnostics []*Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == Error {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	goio "io"
+
+	"github.com/gmisail/glamlang/io"
+)
+
+/*
+Lines and columns both start at 1 so that they match the text output.
+*/
+type jsonLocation struct {
+	File        string `json:"file"`
+	StartLine   int    `json:"startLine"`
+	StartColumn int    `json:"startColumn"`
+	EndLine     int    `json:"endLine"`
+	EndColumn   int    `json:"endColumn"`
+	Message     string `json:"message,omitempty"`
+}
+
+type jsonDiagnostic struct {
+	Severity string         `json:"severity"`
+	Code     string         `json:"code"`
+	Message  string         `json:"message"`
+	Location jsonLocation   `json:"location"`
+	Related  []jsonLocation `json:"related"`
+	Notes    []string       `json:"notes"`
+}
+
+type jsonReport struct {
+	Diagnostics []jsonDiagnostic `json:"diagnostics"`
+}
+
+func createLocation(source *io.SourceFile, label Label) jsonLocation {
+	return jsonLocation{
+		File:        source.Name,
+		StartLine:   label.Span.Start.Line,
+		StartColumn: label.Span.Start.Column + 1,
+		EndLine:     label.Span.End.Line,
+		EndColumn:   label.Span.End.Column + 1,
+		Message:     label.Message,
+	}
+}
+
+/*
+Writes every diagnostic as a single JSON document so that editors and
+scripts can consume them.
+*/
+func RenderJSON(w goio.Writer, source *io.SourceFile, diagnostics []*Diagnostic) error {
+	report := jsonReport{Diagnostics: make([]jsonDiagnostic, 0, len(diagnostics))}
+
+	for _, d := range diagnostics {
+		related := make([]jsonLocation, 0, len(d.Labels))
+
+		for _, label := range d.Labels {
+			related = append(related, createLocation(source, label))
+		}
+
+		report.Diagnostics = append(report.Diagnostics, jsonDiagnostic{
+			Severity: d.Severity.String(),
+			Code:     d.Code,
+			Message:  d.Message,
+			Location: createLocation(source, d.Primary),
+			Related:  related,
+			Notes:    d.Notes,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
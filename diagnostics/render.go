@@ -0,0 +1,170 @@
+package diagnostics
+
+import (
+	"fmt"
+	goio "io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/gmisail/glamlang/io"
+)
+
+var (
+	errorColor   = color.New(color.FgRed, color.Bold)
+	warningColor = color.New(color.FgYellow, color.Bold)
+	noteColor    = color.New(color.FgCyan, color.Bold)
+	gutterColor  = color.New(color.FgBlue, color.Bold)
+	messageColor = color.New(color.Bold)
+)
+
+func severityColor(severity Severity) *color.Color {
+	switch severity {
+	case Warning:
+		return warningColor
+	case Note:
+		return noteColor
+	}
+
+	return errorColor
+}
+
+/*
+Renders a diagnostic in the following form:
+
+	error[P0001]: Unexpected token '}'
+	 --> main.gl:3:13
+	  |
+	3 |     let x = }
+	  |             ^ expected an expression
+	  |
+	  = help: ...
+*/
+func Render(w goio.Writer, source *io.SourceFile, d *Diagnostic) {
+	highlight := severityColor(d.Severity)
+
+	fmt.Fprintf(w, "%s%s\n", highlight.Sprintf("%s[%s]: ", d.Severity.String(), d.Code), messageColor.Sprint(d.Message))
+
+	start := d.Primary.Span.Start
+
+	// nothing to point at, i.e. the error occurred at the end of the file
+	if source == nil || start.Line == 0 {
+		renderNotes(w, "", d.Notes)
+		return
+	}
+
+	labels := append([]Label{d.Primary}, d.Labels...)
+	width := len(strconv.Itoa(maxLine(labels)))
+	gutter := strings.Repeat(" ", width)
+
+	fmt.Fprintf(w, "%s%s %s:%d:%d\n", gutter, gutterColor.Sprint("-->"), source.Name, start.Line, start.Column+1)
+	fmt.Fprintf(w, "%s %s\n", gutter, gutterColor.Sprint("|"))
+
+	// group labels by line so that each line of source is printed once
+	sort.SliceStable(labels, func(i, j int) bool {
+		return labels[i].Span.Start.Line < labels[j].Span.Start.Line
+	})
+
+	for i, label := range labels {
+		line := label.Span.Start.Line
+
+		if i == 0 || labels[i-1].Span.Start.Line != line {
+			number := fmt.Sprintf("%*d", width, line)
+			fmt.Fprintf(w, "%s %s %s\n", gutterColor.Sprint(number), gutterColor.Sprint("|"), source.LineAt(line))
+		}
+
+		underline := "-"
+		underlineColor := gutterColor
+
+		if label == d.Primary {
+			underline = "^"
+			underlineColor = highlight
+		}
+
+		text := source.LineAt(line)
+		marker := strings.Repeat(underline, underlineLength(label.Span, text))
+		message := ""
+
+		if len(label.Message) > 0 {
+			message = " " + label.Message
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s %s %s%s\n",
+			gutter,
+			gutterColor.Sprint("|"),
+			indentation(text, label.Span.Start.Column),
+			underlineColor.Sprint(marker+message),
+		)
+	}
+
+	renderNotes(w, gutter, d.Notes)
+}
+
+func renderNotes(w goio.Writer, gutter string, notes []string) {
+	if len(notes) == 0 {
+		return
+	}
+
+	if len(gutter) > 0 {
+		fmt.Fprintf(w, "%s %s\n", gutter, gutterColor.Sprint("|"))
+	}
+
+	for _, note := range notes {
+		fmt.Fprintf(w, "%s %s %s\n", gutter, gutterColor.Sprint("="), messageColor.Sprintf("help: %s", note))
+	}
+}
+
+func maxLine(labels []Label) int {
+	max := 0
+
+	for _, label := range labels {
+		if label.Span.Start.Line > max {
+			max = label.Span.Start.Line
+		}
+	}
+
+	return max
+}
+
+/*
+Returns the whitespace needed to place a marker under the given column.
+Tabs are kept so that the marker lines up with the source line above it.
+*/
+func indentation(line string, column int) string {
+	var builder strings.Builder
+
+	for i, char := range []rune(line) {
+		if i >= column {
+			break
+		}
+
+		if char == '\t' {
+			builder.WriteRune('\t')
+		} else {
+			builder.WriteRune(' ')
+		}
+	}
+
+	return builder.String()
+}
+
+/*
+Spans that cover multiple lines are underlined until the end of the first line.
+*/
+func underlineLength(span io.Span, line string) int {
+	lineLength := len([]rune(line))
+	length := span.End.Column - span.Start.Column
+
+	if span.End.Line != span.Start.Line || span.Start.Column+length > lineLength {
+		length = lineLength - span.Start.Column
+	}
+
+	if length < 1 {
+		return 1
+	}
+
+	return length
+}
@@ -2,40 +2,145 @@ package io
 
 import (
 	"fmt"
+	goio "io"
 	"os"
 	"strings"
+	"unicode/utf8"
 )
 
+/*
+A location in a source file. Lines start at 1 while columns start at 0 and
+count characters. The offset is measured in bytes.
+*/
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+/*
+The region of a source file between two positions. The end is exclusive.
+*/
+type Span struct {
+	Start Position
+	End   Position
+}
+
+/*
+Creates a span that covers both spans, i.e. from the start of the first
+to the end of the second.
+*/
+func (s Span) To(other Span) Span {
+	return Span{Start: s.Start, End: other.End}
+}
+
 type SourceFile struct {
+	// name used when displaying diagnostics, usually the file path
+	Name     string
 	contents string
 }
 
-func CreateSource(fileName string) *SourceFile {
+/*
+Reads the source file at the given path.
+*/
+func CreateSource(fileName string) (*SourceFile, error) {
 	fileData, err := os.ReadFile(fileName)
 
 	if err != nil {
-		fmt.Errorf(err.Error())
+		return nil, fmt.Errorf("could not read source file: %w", err)
+	}
 
-		return nil
+	return CreateSourceFromBytes(fileName, fileData), nil
+}
+
+/*
+Creates a source file from text that is already in memory.
+*/
+func CreateSourceFromString(name string, contents string) *SourceFile {
+	return &SourceFile{Name: name, contents: contents}
+}
+
+func CreateSourceFromBytes(name string, contents []byte) *SourceFile {
+	return CreateSourceFromString(name, string(contents))
+}
+
+/*
+Reads the entire reader into a source file.
+*/
+func CreateSourceFromReader(name string, reader goio.Reader) (*SourceFile, error) {
+	data, err := goio.ReadAll(reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read source '%s': %w", name, err)
 	}
 
-	return &SourceFile{contents: string(fileData)}
+	return CreateSourceFromBytes(name, data), nil
 }
 
+/*
+Decodes the character starting at byte offset i.
+*/
 func (s *SourceFile) CharAt(i int) rune {
 	if i < len(s.contents) && i >= 0 {
-		return rune(s.contents[i])
+		char, _ := utf8.DecodeRuneInString(s.contents[i:])
+
+		return char
 	}
 
 	return rune(0)
 }
 
+/*
+Returns the number of bytes used by the character starting at byte offset i.
+Offsets outside of the file are treated as a single byte.
+*/
+func (s *SourceFile) CharWidth(i int) int {
+	if i < len(s.contents) && i >= 0 {
+		_, width := utf8.DecodeRuneInString(s.contents[i:])
+
+		return width
+	}
+
+	return 1
+}
+
+func (s *SourceFile) Length() int {
+	return len(s.contents)
+}
+
+/*
+Converts a byte offset into a line (starting at 1) and a column (starting
+at 0). Columns count characters rather than bytes so that they line up
+with what is displayed to the user.
+*/
+func (s *SourceFile) Position(offset int) (int, int) {
+	if offset > len(s.contents) {
+		offset = len(s.contents)
+	}
+
+	line := 1
+	lineStart := 0
+
+	for i := 0; i < offset; i++ {
+		if s.contents[i] == '\n' {
+			line += 1
+			lineStart = i + 1
+		}
+	}
+
+	return line, utf8.RuneCountInString(s.contents[lineStart:offset])
+}
+
 func (s *SourceFile) IsAtEnd(i int) bool {
 	return len(s.contents) <= i
 }
 
+/*
+Returns the text between two byte offsets. The character starting at 'to'
+is included in its entirety.
+*/
 func (s *SourceFile) GetSpan(from int, to int) string {
-	return s.contents[from:(to + 1)]
+	return s.contents[from:(to + s.CharWidth(to))]
 }
 
 func (s *SourceFile) GetLine(absolute int) string {
@@ -66,3 +171,16 @@ func (s *SourceFile) GetLine(absolute int) string {
 
 	return strings.TrimSpace(s.contents[start:end])
 }
+
+/*
+Returns the contents of a line (starting at 1) without its line ending.
+*/
+func (s *SourceFile) LineAt(line int) string {
+	lines := strings.Split(s.contents, "\n")
+
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return strings.TrimRight(lines[line-1], "\r")
+}
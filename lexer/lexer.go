@@ -2,69 +2,237 @@ package lexer
 
 import (
 	"fmt"
+	goio "io"
+	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/gmisail/glamlang/diagnostics"
 	"github.com/gmisail/glamlang/io"
 )
 
 type Lexer struct {
-	current int
-	line    int
-	index   int
-	Tokens  []Token
-	Input   *io.SourceFile
+	current    int
+	line       int
+	index      int
+	start      int
+	startLine  int
+	startIndex int
+
+	// brace depth of every interpolated expression that is currently open
+	interpolations []int
+
+	Tokens []Token
+	Errors []*LexerError
+	Input  *io.SourceFile
 }
 
 type LexerError struct {
-	line    int
+	code    string
+	span    io.Span
 	message string
 }
 
+// a location within the source that is being lexed
+type position struct {
+	absolute int
+	line     int
+	index    int
+}
+
 type TokenPair struct {
 	char      rune
 	tokenType TokenType
 }
 
 var keywords = map[string]TokenType{
-	"let":    LET,
-	"while":  WHILE,
-	"for":    FOR,
-	"if":     IF,
-	"else":   ELSE,
-	"true":   TRUE,
-	"false":  FALSE,
-	"fn":     FUNCTION,
-	"and":    AND,
-	"or":     OR,
-	"type":   TYPE,
-	"mod":    MODULE,
-	"return": RETURN,
-	"new":    NEW,
+	"let":      LET,
+	"mut":      MUT,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"if":       IF,
+	"else":     ELSE,
+	"match":    MATCH,
+	"true":     TRUE,
+	"false":    FALSE,
+	"fn":       FUNCTION,
+	"and":      AND,
+	"or":       OR,
+	"type":     TYPE,
+	"mod":      MODULE,
+	"return":   RETURN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"new":      NEW,
+	"none":     NULL,
+}
+
+type numberRadix struct {
+	name    string
+	base    int
+	isDigit func(rune) bool
+}
+
+var radixes = map[rune]numberRadix{
+	'x': {name: "hexadecimal", base: 16, isDigit: isHexDigit},
+	'o': {name: "octal", base: 8, isDigit: func(char rune) bool { return char >= '0' && char <= '7' }},
+	'b': {name: "binary", base: 2, isDigit: func(char rune) bool { return char == '0' || char == '1' }},
+}
+
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'"':  '"',
+	'$':  '$',
+	'\\': '\\',
 }
 
 func (l *LexerError) Error() string {
-	return fmt.Sprintf("line %d: %s\n", l.line, l.message)
+	return fmt.Sprintf("line %d:%d: %s\n", l.span.Start.Line, l.span.Start.Column+1, l.message)
+}
+
+func (l *LexerError) GetLine() int {
+	return l.span.Start.Line
+}
+
+func (l *LexerError) GetSpan() io.Span {
+	return l.span
+}
+
+func (l *LexerError) Diagnostic() *diagnostics.Diagnostic {
+	return diagnostics.CreateDiagnostic(diagnostics.Error, l.code, l.message, l.span)
+}
+
+/*
+Returns the current position of the lexer.
+*/
+func (l *Lexer) position() position {
+	return position{absolute: l.current, line: l.line, index: l.index}
+}
+
+/*
+Returns the byte offset just after the last character of the current token.
+*/
+func (l *Lexer) end() int {
+	if l.IsAtEnd() {
+		return l.Input.Length()
+	}
+
+	return l.current + l.Input.CharWidth(l.current)
+}
+
+/*
+Creates an error spanning from the given position to the current character.
+*/
+func (l *Lexer) CreateErrorFrom(code string, from position, message string) *LexerError {
+	return &LexerError{
+		code: code,
+		span: io.Span{
+			Start: io.Position{Line: from.line, Column: from.index, Offset: from.absolute},
+			End:   io.Position{Line: l.line, Column: l.index + 1, Offset: l.end()},
+		},
+		message: message,
+	}
+}
+
+/*
+Creates an error spanning the token that is currently being scanned.
+*/
+func (l *Lexer) CreateError(code string, message string) *LexerError {
+	return l.CreateErrorFrom(code, position{absolute: l.start, line: l.startLine, index: l.startIndex}, message)
+}
+
+func (l *Lexer) AddError(err *LexerError) {
+	l.Errors = append(l.Errors, err)
+}
+
+/*
+Records the error and replaces the invalid input with an ERROR token
+so that the parser can continue past it.
+*/
+func (l *Lexer) AddErrorToken(err *LexerError) {
+	// cover everything that was skipped while resynchronising
+	err.span.End = io.Position{Line: l.line, Column: l.index + 1, Offset: l.end()}
+
+	l.AddError(err)
+	l.AddToken(ERROR, l.Input.GetSpan(l.start, l.end()-1))
+}
+
+/*
+Skips the remainder of a malformed word, i.e. the 'px' in 10px, so that
+lexing resumes at the next token.
+*/
+func (l *Lexer) SkipInvalid() {
+	for l.IsLetter(l.PeekChar()) || unicode.IsDigit(l.PeekChar()) {
+		l.AdvanceChar()
+	}
 }
 
 func (l *Lexer) IsAtEnd() bool {
 	return l.Input.IsAtEnd(l.current)
 }
 
+/*
+Moves to the next character. Line and column bookkeeping happens
+here so that every scanner stays accurate across newlines.
+*/
 func (l *Lexer) AdvanceChar() {
-	l.index += 1
-	l.current += 1
+	if l.CurrentChar() == '\n' {
+		l.line += 1
+		l.index = 0
+	} else {
+		l.index += 1
+	}
+
+	// the lexer starts before the first character
+	if l.current < 0 {
+		l.current = 0
+	} else {
+		l.current += l.Input.CharWidth(l.current)
+	}
 }
 
 func (l *Lexer) CharAt(i int) rune {
 	return l.Input.CharAt(i)
 }
 
+/*
+Returns the byte offset of the character following the one at offset.
+*/
+func (l *Lexer) nextOffset(offset int) int {
+	if offset < 0 {
+		return 0
+	}
+
+	return offset + l.Input.CharWidth(offset)
+}
+
 func (l *Lexer) CurrentChar() rune {
 	return l.CharAt(l.current)
 }
 
 func (l *Lexer) PeekChar() rune {
-	return l.CharAt(l.current + 1)
+	return l.CharAt(l.nextOffset(l.current))
+}
+
+func (l *Lexer) PeekNextChar() rune {
+	return l.CharAt(l.nextOffset(l.nextOffset(l.current)))
+}
+
+/*
+Numbers are written with ASCII digits only, even though identifiers may
+contain any unicode letter or digit.
+*/
+func isDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isHexDigit(char rune) bool {
+	return isDigit(char) || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
 }
 
 func (l *Lexer) IsLetter(char rune) bool {
@@ -93,35 +261,161 @@ func (l *Lexer) ScanKeyword() (TokenType, string) {
 	return IDENTIFIER, literal
 }
 
+/*
+Consumes a run of digits following the current character. Digits may be
+separated by single underscores, i.e. 1_000_000. Returns the number of
+digits that were consumed.
+*/
+func (l *Lexer) ScanDigits(isDigit func(rune) bool) (int, *LexerError) {
+	count := 0
+
+	for {
+		nextChar := l.PeekChar()
+
+		if nextChar == '_' {
+			if !isDigit(l.CurrentChar()) || !isDigit(l.PeekNextChar()) {
+				return count, l.CreateError(diagnostics.InvalidNumberCode, "Digit separator '_' must be placed between two digits.")
+			}
+
+			l.AdvanceChar()
+
+			continue
+		}
+
+		if !isDigit(nextChar) {
+			return count, nil
+		}
+
+		l.AdvanceChar()
+		count += 1
+	}
+}
+
+/*
+Scans an integer written with a radix prefix, i.e. 0xFF, 0b1010 or 0o17.
+*/
+func (l *Lexer) ScanRadixNumber(radix numberRadix) (*Token, *LexerError) {
+	// skip the radix prefix
+	l.AdvanceChar()
+
+	count, err := l.ScanDigits(radix.isDigit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	literal := l.Input.GetSpan(l.start, l.current)
+
+	if count == 0 {
+		return nil, l.CreateError(diagnostics.InvalidNumberCode, fmt.Sprintf("Expected %s digits after '%s'.", radix.name, literal))
+	}
+
+	if err := l.CheckNumberSuffix(radix.name); err != nil {
+		return nil, err
+	}
+
+	digits := strings.ReplaceAll(literal[2:], "_", "")
+	value, parseErr := strconv.ParseInt(digits, radix.base, 64)
+
+	if parseErr != nil {
+		return nil, l.CreateError(diagnostics.InvalidNumberCode, fmt.Sprintf("Integer literal '%s' is too large.", literal))
+	}
+
+	return &Token{Type: INT, Literal: literal, Value: value}, nil
+}
+
+/*
+Numbers cannot be immediately followed by a letter or a digit that is
+invalid for its base, i.e. 0b102 or 10px.
+*/
+func (l *Lexer) CheckNumberSuffix(kind string) *LexerError {
+	nextChar := l.PeekChar()
+
+	if l.IsLetter(nextChar) || unicode.IsDigit(nextChar) {
+		return l.CreateError(diagnostics.InvalidNumberCode, fmt.Sprintf("Invalid character '%c' in %s literal.", nextChar, kind))
+	}
+
+	return nil
+}
+
 func (l *Lexer) ScanNumber() (*Token, *LexerError) {
-	start := l.current
+	if l.CurrentChar() == '0' {
+		if radix, ok := radixes[unicode.ToLower(l.PeekChar())]; ok {
+			return l.ScanRadixNumber(radix)
+		}
+	}
+
 	tokenType := INT
 
-	for unicode.IsDigit(l.PeekChar()) {
-		l.AdvanceChar()
+	if _, err := l.ScanDigits(isDigit); err != nil {
+		return nil, err
 	}
 
-	// get numbers after decimal point
-	if l.PeekChar() == '.' {
+	// tuple fields are never fractions, i.e. pair.0.1 accesses two fields
+	isTupleField := len(l.Tokens) > 0 && l.Tokens[len(l.Tokens)-1].Type == PERIOD
+
+	// get numbers after decimal point, unless it is a range such as 0..10
+	if l.PeekChar() == '.' && l.PeekNextChar() != '.' && !isTupleField {
 		l.AdvanceChar()
 
-		initialDecimal := l.current
+		count, err := l.ScanDigits(isDigit)
+
+		if err != nil {
+			return nil, err
+		}
 
-		for unicode.IsDigit(l.PeekChar()) {
+		if count == 0 {
+			return nil, l.CreateError(diagnostics.InvalidNumberCode, "Unexpected '.' after integer.")
+		}
+
+		tokenType = FLOAT
+	}
+
+	// get the exponent, i.e. 6.02e23 or 1e-9
+	if nextChar := l.PeekChar(); nextChar == 'e' || nextChar == 'E' {
+		l.AdvanceChar()
+
+		if sign := l.PeekChar(); sign == '+' || sign == '-' {
 			l.AdvanceChar()
 		}
 
-		if l.current == initialDecimal {
-			return nil, &LexerError{line: l.line, message: "Unexpected '.' after integer."}
+		count, err := l.ScanDigits(isDigit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if count == 0 {
+			return nil, l.CreateError(diagnostics.InvalidNumberCode, "Expected digits in exponent.")
 		}
 
 		tokenType = FLOAT
 	}
 
-	end := l.current
-	literal := l.Input.GetSpan(start, end)
+	if err := l.CheckNumberSuffix("number"); err != nil {
+		return nil, err
+	}
+
+	literal := l.Input.GetSpan(l.start, l.current)
+	digits := strings.ReplaceAll(literal, "_", "")
+
+	if tokenType == FLOAT {
+		value, parseErr := strconv.ParseFloat(digits, 64)
+
+		if parseErr != nil {
+			return nil, l.CreateError(diagnostics.InvalidNumberCode, fmt.Sprintf("Float literal '%s' is out of range.", literal))
+		}
 
-	return &Token{Type: tokenType, Literal: literal}, nil
+		return &Token{Type: FLOAT, Literal: literal, Value: value}, nil
+	}
+
+	value, parseErr := strconv.ParseInt(digits, 10, 64)
+
+	if parseErr != nil {
+		return nil, l.CreateError(diagnostics.InvalidNumberCode, fmt.Sprintf("Integer literal '%s' is too large.", literal))
+	}
+
+	return &Token{Type: INT, Literal: literal, Value: value}, nil
 }
 
 func (l *Lexer) ScanConditional(options []TokenPair, fallback TokenType) TokenType {
@@ -138,70 +432,286 @@ func (l *Lexer) ScanConditional(options []TokenPair, fallback TokenType) TokenTy
 	return fallback
 }
 
-func (l *Lexer) ScanString() string {
-	// ignore opening quote
-	l.AdvanceChar()
+/*
+Scans a string literal. Escape sequences are decoded into the token's
+value while the literal keeps the text exactly as it was written. If the
+string contains an interpolated expression, only the text up to the
+opening '${' is scanned and the expression is lexed as regular tokens.
+*/
+func (l *Lexer) ScanString() (*Token, *LexerError) {
+	if l.PeekChar() == '"' && l.PeekNextChar() == '"' {
+		return l.ScanRawString()
+	}
 
-	start := l.current
+	value, isInterpolated, err := l.ScanStringSegment()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if isInterpolated {
+		l.interpolations = append(l.interpolations, 0)
+		literal := l.Input.GetSpan(l.start+1, l.current-2)
+
+		return &Token{Type: INTERPOLATION_START, Literal: literal, Value: value}, nil
+	}
+
+	literal := l.Input.GetSpan(l.start+1, l.current-1)
+
+	return &Token{Type: STRING, Literal: literal, Value: value}, nil
+}
+
+/*
+Continues scanning an interpolated string after the '}' that closes
+one of its expressions.
+*/
+func (l *Lexer) ResumeString() (*Token, *LexerError) {
+	value, isInterpolated, err := l.ScanStringSegment()
+
+	if err != nil {
+		l.interpolations = l.interpolations[:len(l.interpolations)-1]
+
+		return nil, err
+	}
+
+	if isInterpolated {
+		literal := l.Input.GetSpan(l.start+1, l.current-2)
+
+		return &Token{Type: INTERPOLATION_MIDDLE, Literal: literal, Value: value}, nil
+	}
+
+	l.interpolations = l.interpolations[:len(l.interpolations)-1]
+	literal := l.Input.GetSpan(l.start+1, l.current-1)
+
+	return &Token{Type: INTERPOLATION_END, Literal: literal, Value: value}, nil
+}
+
+/*
+Scans string contents until the closing quote or the beginning of an
+interpolated expression. Returns the decoded contents and whether the
+segment ended with '${'.
+*/
+func (l *Lexer) ScanStringSegment() (string, bool, *LexerError) {
+	var value strings.Builder
 
 	for {
-		nextChar := l.PeekChar()
+		l.AdvanceChar()
+
+		switch currentChar := l.CurrentChar(); currentChar {
+		case 0, '\n':
+			return "", false, l.CreateError(diagnostics.UnterminatedCode, "Unterminated string.")
+		case '"':
+			return value.String(), false, nil
+		case '$':
+			if l.PeekChar() == '{' {
+				l.AdvanceChar()
+
+				return value.String(), true, nil
+			}
+
+			value.WriteRune(currentChar)
+		case '\\':
+			// invalid escapes are recorded, but the rest of the string is still scanned
+			if err := l.ScanEscape(&value); err != nil {
+				l.AddError(err)
+			}
+		default:
+			value.WriteRune(currentChar)
+		}
+	}
+}
+
+/*
+Scans a raw string wrapped in triple quotes. Raw strings may span multiple
+lines and do not process escape sequences.
+*/
+func (l *Lexer) ScanRawString() (*Token, *LexerError) {
+	// skip the remaining opening quotes
+	l.AdvanceChar()
+	l.AdvanceChar()
 
-		if nextChar == '\n' {
-			l.index = 0
-			l.line += 1
-		} else if nextChar == '"' || nextChar == 0 {
+	for {
+		l.AdvanceChar()
+
+		currentChar := l.CurrentChar()
+
+		if currentChar == 0 {
+			return nil, l.CreateError(diagnostics.UnterminatedCode, "Unterminated raw string.")
+		}
+
+		if currentChar == '"' && l.PeekChar() == '"' && l.PeekNextChar() == '"' {
 			break
 		}
+	}
+
+	literal := l.Input.GetSpan(l.start+3, l.current-1)
+
+	// skip the closing quotes
+	l.AdvanceChar()
+	l.AdvanceChar()
+
+	return &Token{Type: STRING, Literal: literal, Value: literal}, nil
+}
+
+/*
+Decodes the escape sequence following a '\' and writes it to value.
+*/
+func (l *Lexer) ScanEscape(value *strings.Builder) *LexerError {
+	start := l.position()
+
+	// leave the end of the line for the string scanner to report
+	if l.PeekChar() == 0 || l.PeekChar() == '\n' {
+		return nil
+	}
+
+	l.AdvanceChar()
+
+	escapedChar := l.CurrentChar()
+
+	if escapedChar == 'u' {
+		return l.ScanUnicodeEscape(value, start)
+	}
+
+	if decoded, ok := escapes[escapedChar]; ok {
+		value.WriteRune(decoded)
+
+		return nil
+	}
+
+	return l.CreateErrorFrom(diagnostics.InvalidEscapeCode, start, fmt.Sprintf("Invalid escape sequence '\\%c'.", escapedChar))
+}
 
+/*
+Decodes a unicode escape of the form \u{1F600}.
+*/
+func (l *Lexer) ScanUnicodeEscape(value *strings.Builder, escapeStart position) *LexerError {
+	if l.PeekChar() != '{' {
+		return l.CreateErrorFrom(diagnostics.InvalidEscapeCode, escapeStart, "Expected '{' after '\\u' in unicode escape.")
+	}
+
+	l.AdvanceChar()
+
+	start := l.current + 1
+
+	for isHexDigit(l.PeekChar()) {
 		l.AdvanceChar()
 	}
 
+	digits := l.Input.GetSpan(start, l.current)
+
+	if l.PeekChar() != '}' {
+		return l.CreateErrorFrom(diagnostics.InvalidEscapeCode, escapeStart, "Expected '}' to close unicode escape.")
+	}
+
 	l.AdvanceChar()
 
-	end := l.current
-	literal := l.Input.GetSpan(start, end-1)
+	if len(digits) == 0 || len(digits) > 6 {
+		return l.CreateErrorFrom(diagnostics.InvalidEscapeCode, escapeStart, "Unicode escape must have between 1 and 6 hex digits.")
+	}
+
+	codePoint, _ := strconv.ParseUint(digits, 16, 32)
+
+	if !utf8.ValidRune(rune(codePoint)) {
+		return l.CreateErrorFrom(diagnostics.InvalidEscapeCode, escapeStart, fmt.Sprintf("Invalid unicode code point '%s'.", digits))
+	}
 
-	return literal
+	value.WriteRune(rune(codePoint))
+
+	return nil
 }
 
-func (l *Lexer) SkipWhitespace() {
+/*
+Skips a comment starting at the current '#'. Line comments run until
+the end of the line, while block comments are wrapped in '#{' and '}#'
+and may be nested.
+*/
+func (l *Lexer) SkipComment() *LexerError {
+	if l.PeekChar() != '{' {
+		for l.PeekChar() != '\n' && l.PeekChar() != 0 {
+			l.AdvanceChar()
+		}
+
+		l.AdvanceChar()
+
+		return nil
+	}
+
+	start := l.position()
+	depth := 0
+
 	for {
-		switch l.CurrentChar() {
-		case '\r', '\t', ' ':
+		currentChar := l.CurrentChar()
+
+		if currentChar == 0 {
+			return l.CreateErrorFrom(diagnostics.UnterminatedCode, start, "Unterminated block comment.")
+		}
+
+		if currentChar == '#' && l.PeekChar() == '{' {
+			depth += 1
 			l.AdvanceChar()
-		case '\n':
-			l.index = 0
-			l.line += 1
+		} else if currentChar == '}' && l.PeekChar() == '#' {
+			depth -= 1
 			l.AdvanceChar()
+		}
+
+		l.AdvanceChar()
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func (l *Lexer) SkipWhitespace() *LexerError {
+	for {
+		switch l.CurrentChar() {
+		case '\r', '\t', ' ', '\n':
+			l.AdvanceChar()
+		case '#':
+			if err := l.SkipComment(); err != nil {
+				return err
+			}
 		default:
-			return
+			return nil
 		}
 	}
 }
 
+/*
+Marks the current character as the beginning of the next token.
+*/
+func (l *Lexer) StartToken() {
+	l.start = l.current
+	l.startLine = l.line
+	l.startIndex = l.index
+}
+
 func (l *Lexer) AddToken(tokenType TokenType, literal string) Token {
+	return l.AddLiteral(tokenType, literal, nil)
+}
+
+/*
+Adds a token that carries a decoded value alongside its literal, such
+as a string with its escape sequences resolved.
+*/
+func (l *Lexer) AddLiteral(tokenType TokenType, literal string, value interface{}) Token {
 	t := Token{
 		Type:     tokenType,
 		Literal:  literal,
-		Line:     l.line,
-		Absolute: l.current - len(literal) + 1,
-		Relative: l.index - len(literal) + 1,
-		Length:   len(literal),
-	}
-
-	if t.Type == STRING {
-		t.Absolute -= 2
-		t.Relative -= 2
-		t.Length += 2
+		Value:    value,
+		Line:     l.startLine,
+		Absolute: l.start,
+		Relative: l.startIndex,
+		Length:   l.end() - l.start,
+		Span: io.Span{
+			Start: io.Position{Line: l.startLine, Column: l.startIndex, Offset: l.start},
+			End:   io.Position{Line: l.line, Column: l.index + 1, Offset: l.end()},
+		},
 	}
 
 	symbol := GetSymbol(tokenType)
 
 	if len(symbol) != 0 {
-		t.Length = len(symbol)
-		t.Absolute = l.current - t.Length + 1
-		t.Relative = l.index - t.Length + 1
 		t.Literal = symbol
 	}
 
@@ -214,14 +724,42 @@ func (l *Lexer) AddKeyword(tokenType TokenType) Token {
 	return l.AddToken(tokenType, "")
 }
 
+/*
+Called once the end of the input has been reached. Reports any
+interpolated expression that was never closed and marks the end of the
+token stream.
+*/
+func (l *Lexer) ScanEnd() bool {
+	if len(l.interpolations) > 0 {
+		l.AddError(l.CreateErrorFrom(diagnostics.UnterminatedCode, l.position(), "Unterminated string interpolation."))
+	}
+
+	end := io.Position{Line: l.line, Column: l.index, Offset: l.Input.Length()}
+
+	l.Tokens = append(l.Tokens, Token{
+		Type:     END_OF_FILE,
+		Line:     end.Line,
+		Absolute: end.Offset,
+		Relative: end.Column,
+		Span:     io.Span{Start: end, End: end},
+	})
+
+	return false
+}
+
 func (l *Lexer) ScanToken() bool {
 	l.AdvanceChar()
 
 	if l.IsAtEnd() {
-		return false
+		return l.ScanEnd()
 	}
 
-	l.SkipWhitespace()
+	if err := l.SkipWhitespace(); err != nil {
+		l.AddError(err)
+		return l.ScanEnd()
+	}
+
+	l.StartToken()
 
 	currentChar := l.CurrentChar()
 
@@ -231,8 +769,32 @@ func (l *Lexer) ScanToken() bool {
 	case ')':
 		l.AddKeyword(R_PAREN)
 	case '{':
+		if depth := len(l.interpolations); depth > 0 {
+			l.interpolations[depth-1] += 1
+		}
+
 		l.AddKeyword(L_BRACE)
 	case '}':
+		depth := len(l.interpolations)
+
+		// a '}' at the top level of an interpolated expression resumes the string
+		if depth > 0 && l.interpolations[depth-1] == 0 {
+			token, err := l.ResumeString()
+
+			if err != nil {
+				l.AddErrorToken(err)
+				break
+			}
+
+			l.AddLiteral(token.Type, token.Literal, token.Value)
+
+			break
+		}
+
+		if depth > 0 {
+			l.interpolations[depth-1] -= 1
+		}
+
 		l.AddKeyword(R_BRACE)
 	case '[':
 		l.AddKeyword(L_BRACKET)
@@ -241,15 +803,26 @@ func (l *Lexer) ScanToken() bool {
 	case ',':
 		l.AddKeyword(COMMA)
 	case '.':
-		l.AddKeyword(PERIOD)
+		tokenType := l.ScanConditional([]TokenPair{{char: '.', tokenType: RANGE}}, PERIOD)
+
+		if tokenType == RANGE {
+			tokenType = l.ScanConditional([]TokenPair{{char: '=', tokenType: RANGE_INCLUSIVE}}, RANGE)
+		}
+
+		l.AddKeyword(tokenType)
 	case '+':
-		l.AddKeyword(ADD)
+		l.AddKeyword(l.ScanConditional([]TokenPair{{char: '=', tokenType: ADD_EQUAL}}, ADD))
 	case '-':
-		l.AddKeyword(l.ScanConditional([]TokenPair{{char: '>', tokenType: ARROW}}, SUB))
+		l.AddKeyword(
+			l.ScanConditional(
+				[]TokenPair{{char: '>', tokenType: ARROW}, {char: '=', tokenType: SUB_EQUAL}},
+				SUB,
+			),
+		)
 	case '*':
-		l.AddKeyword(MULT)
+		l.AddKeyword(l.ScanConditional([]TokenPair{{char: '=', tokenType: MULT_EQUAL}}, MULT))
 	case '/':
-		l.AddKeyword(DIV)
+		l.AddKeyword(l.ScanConditional([]TokenPair{{char: '=', tokenType: DIV_EQUAL}}, DIV))
 	case '=':
 		l.AddKeyword(
 			l.ScanConditional(
@@ -266,11 +839,20 @@ func (l *Lexer) ScanToken() bool {
 	case ':':
 		l.AddKeyword(COLON)
 	case '"':
-		l.AddToken(STRING, l.ScanString())
+		token, err := l.ScanString()
+
+		if err != nil {
+			l.AddErrorToken(err)
+			break
+		}
+
+		l.AddLiteral(token.Type, token.Literal, token.Value)
 	case '?':
 		l.AddKeyword(QUESTION)
+	case '|':
+		l.AddKeyword(PIPE)
 	case 0:
-		return false
+		return l.ScanEnd()
 	default:
 		if l.IsLetter(currentChar) {
 			tokenType, literal := l.ScanKeyword()
@@ -280,27 +862,68 @@ func (l *Lexer) ScanToken() bool {
 			} else {
 				l.AddKeyword(tokenType)
 			}
-		} else if unicode.IsDigit(currentChar) {
+		} else if isDigit(currentChar) {
 			token, err := l.ScanNumber()
 
 			if err != nil {
-				fmt.Print(err.Error())
-				return false
+				l.SkipInvalid()
+				l.AddErrorToken(err)
+				break
 			}
 
-			l.AddToken(token.Type, token.Literal)
+			l.AddLiteral(token.Type, token.Literal, token.Value)
 		} else {
-			fmt.Printf("line %d: Unknown token: '%c'\n", l.line, currentChar)
-			return false
+			l.AddErrorToken(l.CreateError(diagnostics.InvalidCharacterCode, fmt.Sprintf("Unknown token: '%c'", currentChar)))
 		}
 	}
 
 	return true
 }
 
-func ScanTokens(fileName string) *Lexer {
-	source := io.CreateSource(fileName)
-	lexer := Lexer{current: -1, line: 1, index: -1, Input: source, Tokens: make([]Token, 0)}
+/*
+Lexes the file at the given path.
+*/
+func ScanFile(fileName string) (*Lexer, error) {
+	source, err := io.CreateSource(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ScanTokens(source), nil
+}
+
+/*
+Lexes source text that is already in memory. The name is only used
+for display, i.e. in diagnostics.
+*/
+func ScanText(name string, text string) *Lexer {
+	return ScanTokens(io.CreateSourceFromString(name, text))
+}
+
+func ScanBytes(name string, data []byte) *Lexer {
+	return ScanTokens(io.CreateSourceFromBytes(name, data))
+}
+
+func ScanReader(name string, reader goio.Reader) (*Lexer, error) {
+	source, err := io.CreateSourceFromReader(name, reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ScanTokens(source), nil
+}
+
+func ScanTokens(source *io.SourceFile) *Lexer {
+	lexer := Lexer{
+		current: -1,
+		line:    1,
+		index:   -1,
+		Input:   source,
+		Tokens:  make([]Token, 0),
+		Errors:  make([]*LexerError, 0),
+	}
 
 	for lexer.ScanToken() {
 	}
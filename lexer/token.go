@@ -1,6 +1,10 @@
 package lexer
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/gmisail/glamlang/io"
+)
 
 type TokenType int64
 
@@ -14,6 +18,10 @@ const (
 	SUB
 	MULT
 	DIV
+	ADD_EQUAL
+	SUB_EQUAL
+	MULT_EQUAL
+	DIV_EQUAL
 	GT
 	GT_EQ
 	LT
@@ -23,8 +31,11 @@ const (
 	NOT_EQUAL
 	COMMA
 	PERIOD
+	RANGE
+	RANGE_INCLUSIVE
 	COLON
 	QUESTION
+	PIPE
 	L_PAREN
 	R_PAREN
 	L_BRACE
@@ -33,12 +44,20 @@ const (
 	R_BRACKET
 	QUOTE
 	STRING
+	INTERPOLATION_START
+	INTERPOLATION_MIDDLE
+	INTERPOLATION_END
 	LET
+	MUT
 	WHILE
 	FOR
+	IN
 	IF
 	ELSE
+	MATCH
 	RETURN
+	BREAK
+	CONTINUE
 	FUNCTION
 	TYPE
 	MODULE
@@ -52,6 +71,7 @@ const (
 	NULL
 	FLOAT
 	INT
+	ERROR
 )
 
 func TokenTypeToString(token TokenType) string {
@@ -74,6 +94,14 @@ func TokenTypeToString(token TokenType) string {
 		return "MULT"
 	case DIV:
 		return "DIV"
+	case ADD_EQUAL:
+		return "ADD_EQUAL"
+	case SUB_EQUAL:
+		return "SUB_EQUAL"
+	case MULT_EQUAL:
+		return "MULT_EQUAL"
+	case DIV_EQUAL:
+		return "DIV_EQUAL"
 	case GT:
 		return "GT"
 	case GT_EQ:
@@ -92,6 +120,10 @@ func TokenTypeToString(token TokenType) string {
 		return "COMMA"
 	case PERIOD:
 		return "PERIOD"
+	case RANGE:
+		return "RANGE"
+	case RANGE_INCLUSIVE:
+		return "RANGE_INCLUSIVE"
 	case COLON:
 		return "COLON"
 	case L_PAREN:
@@ -110,18 +142,34 @@ func TokenTypeToString(token TokenType) string {
 		return "QUOTE"
 	case STRING:
 		return "STRING"
+	case INTERPOLATION_START:
+		return "INTERPOLATION_START"
+	case INTERPOLATION_MIDDLE:
+		return "INTERPOLATION_MIDDLE"
+	case INTERPOLATION_END:
+		return "INTERPOLATION_END"
 	case LET:
 		return "LET"
+	case MUT:
+		return "MUT"
 	case WHILE:
 		return "WHILE"
 	case FOR:
 		return "FOR"
+	case IN:
+		return "IN"
 	case IF:
 		return "IF"
 	case ELSE:
 		return "ELSE"
+	case MATCH:
+		return "MATCH"
 	case RETURN:
 		return "RETURN"
+	case BREAK:
+		return "BREAK"
+	case CONTINUE:
+		return "CONTINUE"
 	case TYPE:
 		return "TYPE"
 	case MODULE:
@@ -138,6 +186,8 @@ func TokenTypeToString(token TokenType) string {
 		return "THICK_ARROW"
 	case QUESTION:
 		return "QUESTION"
+	case PIPE:
+		return "PIPE"
 	case TRUE:
 		return "TRUE"
 	case FALSE:
@@ -148,6 +198,8 @@ func TokenTypeToString(token TokenType) string {
 		return "FLOAT"
 	case INT:
 		return "INT"
+	case ERROR:
+		return "ERROR"
 	}
 
 	return "?"
@@ -165,6 +217,14 @@ func GetSymbol(token TokenType) string {
 		return "*"
 	case DIV:
 		return "/"
+	case ADD_EQUAL:
+		return "+="
+	case SUB_EQUAL:
+		return "-="
+	case MULT_EQUAL:
+		return "*="
+	case DIV_EQUAL:
+		return "/="
 	case GT:
 		return ">"
 	case GT_EQ:
@@ -183,6 +243,10 @@ func GetSymbol(token TokenType) string {
 		return ","
 	case PERIOD:
 		return "."
+	case RANGE:
+		return ".."
+	case RANGE_INCLUSIVE:
+		return "..="
 	case COLON:
 		return ":"
 	case L_PAREN:
@@ -205,6 +269,8 @@ func GetSymbol(token TokenType) string {
 		return "=>"
 	case QUESTION:
 		return "?"
+	case PIPE:
+		return "|"
 	case NULL:
 		return "none"
 	case TRUE:
@@ -213,16 +279,26 @@ func GetSymbol(token TokenType) string {
 		return "false"
 	case LET:
 		return "let"
+	case MUT:
+		return "mut"
 	case WHILE:
 		return "while"
 	case FOR:
 		return "for"
+	case IN:
+		return "in"
 	case IF:
 		return "if"
 	case ELSE:
 		return "else"
+	case MATCH:
+		return "match"
 	case RETURN:
 		return "return"
+	case BREAK:
+		return "break"
+	case CONTINUE:
+		return "continue"
 	case TYPE:
 		return "type"
 	case MODULE:
@@ -243,8 +319,10 @@ func (t *Token) Print() {
 type Token struct {
 	Type     TokenType
 	Literal  string
+	Value    interface{}
 	Line     int
 	Relative int
 	Absolute int
 	Length   int
+	Span     io.Span
 }
@@ -1,34 +1,89 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/gmisail/glamlang/diagnostics"
 	"github.com/gmisail/glamlang/lexer"
 	"github.com/gmisail/glamlang/parser"
 	"github.com/gmisail/glamlang/typechecker"
 )
 
 func main() {
-	fileName := os.Args[1]
+	format := flag.String("diagnostics", "text", "format of reported diagnostics: text or json")
+	flag.Parse()
+
+	if flag.NArg() < 1 || (*format != "text" && *format != "json") {
+		fmt.Fprintln(os.Stderr, "usage: glamlang [-diagnostics=text|json] <file>")
+		os.Exit(2)
+	}
+
+	// timing messages would corrupt the JSON written to stdout
+	verbose := *format == "text"
+	report := make([]*diagnostics.Diagnostic, 0)
 
 	start := time.Now()
-	l := lexer.ScanTokens(fileName)
-	color.Blue("[glam] Done lexing in %s.", time.Since(start))
+	l, err := lexer.ScanFile(flag.Arg(0))
+
+	// errors are written to stderr so that stdout only ever contains diagnostics
+	if err != nil {
+		color.New(color.FgRed).Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if verbose {
+		color.Blue("[glam] Done lexing in %s.", time.Since(start))
+	}
+
+	for _, lexerErr := range l.Errors {
+		report = append(report, lexerErr.Diagnostic())
+	}
 
 	start = time.Now()
-	ok, statements := parser.Parse(l, l.Tokens)
-	color.Blue("[glam] Done parsing in %s.", time.Since(start))
+	statements, parseErrors := parser.ParseProgram(l, l.Tokens)
+
+	if verbose {
+		color.Blue("[glam] Done parsing in %s.", time.Since(start))
+	}
 
-	if !ok {
-		return
+	for _, parseErr := range parseErrors {
+		report = append(report, parseErr.Diagnostic())
 	}
 
+	// statements which failed to parse are skipped, so the rest can still be checked
 	checker := typechecker.CreateTypeChecker()
 	start = time.Now()
 
 	checker.CheckAll(statements)
 
-	color.Blue("[glam] Done type checking in %s.", time.Since(start))
+	if verbose {
+		color.Blue("[glam] Done type checking in %s.", time.Since(start))
+	}
+
+	for _, typeErr := range checker.Errors {
+		report = append(report, typeErr.Diagnostic())
+	}
+
+	for _, warning := range checker.Warnings {
+		report = append(report, warning.Diagnostic())
+	}
+
+	if *format == "json" {
+		if err := diagnostics.RenderJSON(os.Stdout, l.Input, report); err != nil {
+			color.New(color.FgRed).Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	} else {
+		for _, diagnostic := range report {
+			diagnostics.Render(os.Stderr, l.Input, diagnostic)
+		}
+	}
+
+	if diagnostics.HasErrors(report) {
+		os.Exit(1)
+	}
 }
@@ -1,20 +1,41 @@
 package parser
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
+)
+
+/*
+Returned when a statement contains a token that the lexer couldn't scan. The
+lexer has already reported it, so this is not collected as a ParseError.
+*/
+var errInvalidToken = errors.New("statement contains an invalid token")
 
 type ParseError struct {
-	line    int
+	code    string
+	span    io.Span
 	message string
 }
 
 func (p *ParseError) Error() string {
-	if p.line == 0 {
+	if p.span.Start.Line == 0 {
 		return fmt.Sprintf("EOF: %s", p.message)
 	}
 
-	return fmt.Sprintf("line %d: %s", p.line, p.message)
+	return fmt.Sprintf("line %d:%d: %s", p.span.Start.Line, p.span.Start.Column+1, p.message)
+}
+
+func (p *ParseError) GetSpan() io.Span {
+	return p.span
+}
+
+func (p *ParseError) Diagnostic() *diagnostics.Diagnostic {
+	return diagnostics.CreateDiagnostic(diagnostics.Error, p.code, p.message, p.span)
 }
 
-func CreateParseError(line int, message string) *ParseError {
-	return &ParseError{line, message}
+func CreateParseError(code string, span io.Span, message string) *ParseError {
+	return &ParseError{code, span, message}
 }
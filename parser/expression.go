@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
 	"github.com/gmisail/glamlang/lexer"
 )
 
@@ -11,35 +12,61 @@ func (p *Parser) parsePrimary() (ast.Expression, error) {
 
 	if p.MatchToken(lexer.FALSE) {
 		return &ast.Literal{
-			NodeMetadata: ast.CreateMetadata(token.Line),
+			NodeMetadata: ast.CreateMetadata(token.Span),
 			Value:        false,
 			LiteralType:  lexer.BOOL,
 		}, nil
 	} else if p.MatchToken(lexer.TRUE) {
-		return &ast.Literal{NodeMetadata: ast.CreateMetadata(token.Line), Value: true, LiteralType: lexer.BOOL}, nil
+		return &ast.Literal{NodeMetadata: ast.CreateMetadata(token.Span), Value: true, LiteralType: lexer.BOOL}, nil
 	} else if p.MatchToken(lexer.NULL) {
-		return &ast.Literal{NodeMetadata: ast.CreateMetadata(token.Line), Value: nil, LiteralType: lexer.NULL}, nil
+		return &ast.Literal{NodeMetadata: ast.CreateMetadata(token.Span), Value: nil, LiteralType: lexer.NULL}, nil
 	} else if p.MatchToken(lexer.STRING, lexer.INT, lexer.FLOAT) {
-		return &ast.Literal{NodeMetadata: ast.CreateMetadata(token.Line), Value: token.Literal, LiteralType: token.Type}, nil
+		return &ast.Literal{NodeMetadata: ast.CreateMetadata(token.Span), Value: token.Value, LiteralType: token.Type}, nil
+	} else if p.MatchToken(lexer.ERROR) {
+		// the lexer has already reported this token, so avoid a second error
+		return &ast.BadExpression{NodeMetadata: ast.CreateMetadata(token.Span)}, nil
+	} else if p.MatchToken(lexer.INTERPOLATION_START) {
+		return p.parseInterpolation()
 	} else if p.MatchToken(lexer.IDENTIFIER) {
 		value := p.PreviousToken().Literal
 
-		if p.MatchToken(lexer.L_BRACE) {
-			return p.parseRecordInstantiation(value)
+		if !p.noRecordInstance && p.MatchToken(lexer.L_BRACE) {
+			return p.parseRecordInstantiation(token)
 		}
 
-		return &ast.VariableExpression{NodeMetadata: ast.CreateMetadata(token.Line), Value: value}, nil
+		return &ast.VariableExpression{NodeMetadata: ast.CreateMetadata(token.Span), Value: value}, nil
+	} else if p.MatchToken(lexer.L_BRACKET) {
+		return p.parseCollectionLiteral()
+	} else if p.MatchToken(lexer.MATCH) {
+		return p.parseMatchExpression()
 	} else if p.MatchToken(lexer.L_PAREN) {
-		expr, _ := p.parseExpression()
-		//benabenabenabenabenabenabenabenabenabenabenabenabenabena
-		//yayayayayayayayayayaayayayayayaya
+		// records can be created within parentheses, i.e. match (Point { x: 1 }) { ... }
+		noRecordInstance := p.noRecordInstance
+		p.noRecordInstance = false
+
+		expr, exprErr := p.parseExpression()
+
+		p.noRecordInstance = noRecordInstance
+
+		if exprErr != nil {
+			return nil, exprErr
+		}
+
+		if p.CheckCurrent(lexer.COMMA) {
+			return p.parseTupleLiteral(token, expr)
+		}
+
 		_, err := p.Consume(lexer.R_PAREN, "Expected closing parenthesis for group expression.")
 
 		if err != nil {
 			return nil, err
 		}
 
-		return &ast.Group{NodeMetadata: ast.CreateMetadata(token.Line), Value: expr}, nil
+		return &ast.Group{NodeMetadata: ast.CreateMetadata(p.spanFrom(token)), Value: expr}, nil
+	}
+
+	if p.isAtEnd() {
+		return nil, CreateParseError(diagnostics.UnexpectedEndCode, p.endOfInput(), "Unexpected end of input.")
 	}
 
 	literal := p.CurrentToken().Literal
@@ -47,20 +74,65 @@ func (p *Parser) parsePrimary() (ast.Expression, error) {
 		literal = lexer.GetSymbol(p.CurrentToken().Type)
 	}
 
-	fmt.Println(p.Lexer.Input.GetLine(p.CurrentToken().Absolute))
-
-	return nil, &ParseError{
-		message: fmt.Sprintf(
+	return nil, CreateParseError(
+		diagnostics.UnexpectedTokenCode,
+		p.CurrentToken().Span,
+		fmt.Sprintf(
 			"Unexpected token '%s'",
 			literal,
 		),
-		line: p.CurrentToken().Line,
+	)
+}
+
+/*
+Parses an interpolated string, i.e. "hello ${name}!", into the string
+segments and expressions that make it up. Empty segments are dropped.
+*/
+func (p *Parser) parseInterpolation() (ast.Expression, error) {
+	start := p.PreviousToken()
+	parts := make([]ast.Expression, 0)
+	segment := start
+
+	for {
+		if value, _ := segment.Value.(string); len(value) > 0 {
+			parts = append(parts, &ast.Literal{
+				NodeMetadata: ast.CreateMetadata(segment.Span),
+				Value:        value,
+				LiteralType:  lexer.STRING,
+			})
+		}
+
+		if segment.Type == lexer.INTERPOLATION_END {
+			break
+		}
+
+		expr, exprErr := p.parseExpression()
+
+		if exprErr != nil {
+			return nil, exprErr
+		}
+
+		parts = append(parts, expr)
+
+		if p.MatchToken(lexer.INTERPOLATION_MIDDLE) {
+			segment = p.PreviousToken()
+
+			continue
+		}
+
+		end, endErr := p.Consume(lexer.INTERPOLATION_END, "Expected '}' after interpolated expression.")
+
+		if endErr != nil {
+			return nil, endErr
+		}
+
+		segment = end
 	}
+
+	return &ast.Interpolation{NodeMetadata: ast.CreateMetadata(p.spanFrom(start)), Parts: parts}, nil
 }
 
-func (p *Parser) parseRecordInstantiation(baseType string) (ast.Expression, error) {
-	// get the line number of the opening '{'
-	line := p.PreviousToken().Line
+func (p *Parser) parseRecordInstantiation(baseType *lexer.Token) (ast.Expression, error) {
 	values := make(map[string]ast.Expression)
 
 	for {
@@ -90,13 +162,220 @@ func (p *Parser) parseRecordInstantiation(baseType string) (ast.Expression, erro
 		values[variableName.Literal] = variableValue
 	}
 
-	return &ast.RecordInstance{NodeMetadata: ast.CreateMetadata(line), Values: values}, nil
+	return &ast.RecordInstance{
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(baseType)),
+		Name:         baseType.Literal,
+		Values:       values,
+	}, nil
+}
+
+/*
+Parses a match expression, i.e. match shape { Circle { r } => r, _ => 0.0 }.
+Arms are separated by commas, and the last arm may have a trailing comma.
+*/
+func (p *Parser) parseMatchExpression() (ast.Expression, error) {
+	start := p.PreviousToken()
+
+	noRecordInstance := p.noRecordInstance
+	defer func() { p.noRecordInstance = noRecordInstance }()
+
+	p.noRecordInstance = true
+	value, valueErr := p.parseExpression()
+	p.noRecordInstance = false
+
+	if valueErr != nil {
+		return nil, valueErr
+	}
+
+	_, leftBraceErr := p.Consume(lexer.L_BRACE, "Expected '{' after value in match expression.")
+
+	if leftBraceErr != nil {
+		return nil, leftBraceErr
+	}
+
+	if p.CheckCurrent(lexer.R_BRACE) {
+		return nil, CreateParseError(diagnostics.UnexpectedTokenCode, p.CurrentToken().Span, "Expected at least one arm in match expression.")
+	}
+
+	arms := make([]*ast.MatchArm, 0)
+
+	for hasComma := true; hasComma && !p.CheckCurrent(lexer.R_BRACE); hasComma = p.MatchToken(lexer.COMMA) {
+		armStart := p.CurrentToken()
+		pattern, patternErr := p.parsePattern()
+
+		if patternErr != nil {
+			return nil, patternErr
+		}
+
+		var guard ast.Expression
+
+		if p.MatchToken(lexer.IF) {
+			condition, conditionErr := p.parseExpression()
+
+			if conditionErr != nil {
+				return nil, conditionErr
+			}
+
+			guard = condition
+		}
+
+		_, arrowErr := p.Consume(lexer.THICK_ARROW, "Expected '=>' after pattern in match arm.")
+
+		if arrowErr != nil {
+			return nil, arrowErr
+		}
+
+		body, bodyErr := p.parseExpression()
+
+		if bodyErr != nil {
+			return nil, bodyErr
+		}
+
+		arms = append(arms, &ast.MatchArm{Pattern: pattern, Guard: guard, Body: body, Span: p.spanFrom(armStart)})
+	}
+
+	_, rightBraceErr := p.Consume(lexer.R_BRACE, "Expected '}' after arms of match expression.")
+
+	if rightBraceErr != nil {
+		return nil, rightBraceErr
+	}
+
+	return &ast.MatchExpression{Value: value, Arms: arms, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+}
+
+/*
+Parses the rest of a tuple literal, i.e. (1, "one"), given its first value.
+*/
+func (p *Parser) parseTupleLiteral(start *lexer.Token, first ast.Expression) (ast.Expression, error) {
+	values := []ast.Expression{first}
+
+	for p.MatchToken(lexer.COMMA) {
+		value, valueErr := p.parseExpression()
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		values = append(values, value)
+	}
+
+	_, rightParenErr := p.Consume(lexer.R_PAREN, "Expected ')' after tuple values.")
+
+	if rightParenErr != nil {
+		return nil, rightParenErr
+	}
+
+	return &ast.TupleLiteral{Values: values, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
 }
 
-func (p *Parser) finishParseCall(startLine int, callee ast.Expression) (ast.Expression, error) {
+/*
+Parses an array literal, i.e. [1, 2, 3], or a map literal if the first value
+is followed by a colon, i.e. ["one": 1]. An empty map is written as [:].
+*/
+func (p *Parser) parseCollectionLiteral() (ast.Expression, error) {
+	start := p.PreviousToken()
+
+	if p.MatchToken(lexer.COLON) {
+		_, rightBracketErr := p.Consume(lexer.R_BRACKET, "Expected ']' after ':' in empty map.")
+
+		if rightBracketErr != nil {
+			return nil, rightBracketErr
+		}
+
+		return &ast.MapLiteral{Entries: make([]ast.MapEntry, 0), NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+	}
+
+	if p.MatchToken(lexer.R_BRACKET) {
+		return &ast.ArrayLiteral{Values: make([]ast.Expression, 0), NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+	}
+
+	first, firstErr := p.parseExpression()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if p.CheckCurrent(lexer.COLON) {
+		return p.parseMapLiteral(start, first)
+	}
+
+	return p.parseArrayLiteral(start, first)
+}
+
+/*
+Parses the rest of the values of an array literal. A trailing comma is
+allowed so that each value can be placed on its own line.
+*/
+func (p *Parser) parseArrayLiteral(start *lexer.Token, first ast.Expression) (ast.Expression, error) {
+	values := []ast.Expression{first}
+
+	for p.MatchToken(lexer.COMMA) && !p.CheckCurrent(lexer.R_BRACKET) {
+		value, valueErr := p.parseExpression()
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		values = append(values, value)
+	}
+
+	_, rightBracketErr := p.Consume(lexer.R_BRACKET, "Expected ']' after array values.")
+
+	if rightBracketErr != nil {
+		return nil, rightBracketErr
+	}
+
+	return &ast.ArrayLiteral{Values: values, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+}
+
+/*
+Parses the entries of a map literal given its first key.
+*/
+func (p *Parser) parseMapLiteral(start *lexer.Token, firstKey ast.Expression) (ast.Expression, error) {
+	entries := make([]ast.MapEntry, 0)
+	key := firstKey
+
+	for {
+		_, colonErr := p.Consume(lexer.COLON, "Expected ':' after map key.")
+
+		if colonErr != nil {
+			return nil, colonErr
+		}
+
+		value, valueErr := p.parseExpression()
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		entries = append(entries, ast.MapEntry{Key: key, Value: value})
+
+		if !p.MatchToken(lexer.COMMA) || p.CheckCurrent(lexer.R_BRACKET) {
+			break
+		}
+
+		nextKey, keyErr := p.parseExpression()
+
+		if keyErr != nil {
+			return nil, keyErr
+		}
+
+		key = nextKey
+	}
+
+	_, rightBracketErr := p.Consume(lexer.R_BRACKET, "Expected ']' after map entries.")
+
+	if rightBracketErr != nil {
+		return nil, rightBracketErr
+	}
+
+	return &ast.MapLiteral{Entries: entries, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+}
+
+func (p *Parser) finishParseCall(callee ast.Expression) (ast.Expression, error) {
 	arguments := make([]ast.Expression, 0)
 
-	if p.CurrentToken().Type != lexer.R_PAREN {
+	if !p.CheckCurrent(lexer.R_PAREN) {
 		for hasComma := true; hasComma; hasComma = p.MatchToken(lexer.COMMA) {
 			argument, argumentErr := p.parseExpression()
 
@@ -117,7 +396,7 @@ func (p *Parser) finishParseCall(startLine int, callee ast.Expression) (ast.Expr
 	return &ast.FunctionCall{
 		Callee:       callee,
 		Arguments:    arguments,
-		NodeMetadata: ast.CreateMetadata(startLine),
+		NodeMetadata: ast.CreateMetadata(callee.GetSpan().To(p.PreviousToken().Span)),
 	}, nil
 }
 
@@ -132,17 +411,54 @@ func (p *Parser) parseCall() (ast.Expression, error) {
 
 	for {
 		if p.MatchToken(lexer.L_PAREN) {
-			line := p.PreviousToken().Line
-			expr, callErr = p.finishParseCall(line, expr)
+			expr, callErr = p.finishParseCall(expr)
 
 			if callErr != nil {
 				return nil, callErr
 			}
+		} else if p.MatchToken(lexer.L_BRACKET) {
+			index, indexErr := p.parseExpression()
+
+			if indexErr != nil {
+				return nil, indexErr
+			}
+
+			_, rightBracketErr := p.Consume(lexer.R_BRACKET, "Expected ']' after index.")
+
+			if rightBracketErr != nil {
+				return nil, rightBracketErr
+			}
+
+			expr = &ast.IndexExpression{
+				Collection:   expr,
+				Index:        index,
+				NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(p.PreviousToken().Span)),
+			}
 		} else if p.MatchToken(lexer.PERIOD) {
-			// TODO: handle me
-			line := p.PreviousToken().Line
-			name, _ := p.Consume(lexer.IDENTIFIER, "Expected identifier after '.'")
-			expr = &ast.GetExpression{Name: name.Literal, Parent: expr, NodeMetadata: ast.CreateMetadata(line)}
+			// tuple fields are accessed by position, i.e. pair.0
+			if p.MatchToken(lexer.INT) {
+				position := p.PreviousToken()
+
+				expr = &ast.GetExpression{
+					Name:         fmt.Sprint(position.Value),
+					Parent:       expr,
+					NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(position.Span)),
+				}
+
+				continue
+			}
+
+			name, nameErr := p.Consume(lexer.IDENTIFIER, "Expected identifier after '.'")
+
+			if nameErr != nil {
+				return nil, nameErr
+			}
+
+			expr = &ast.GetExpression{
+				Name:         name.Literal,
+				Parent:       expr,
+				NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(name.Span)),
+			}
 		} else {
 			break
 		}
@@ -153,16 +469,28 @@ func (p *Parser) parseCall() (ast.Expression, error) {
 
 func (p *Parser) parseFunction() (ast.Expression, error) {
 	if p.MatchToken(lexer.FUNCTION) {
+		start := p.PreviousToken()
 		parameters := make([]ast.VariableDeclaration, 0)
 
-		leftParen, leftParenErr := p.Consume(lexer.L_PAREN, "Expected '('")
+		var typeParameters []string
+
+		// fn<T>(x: T): T => x
+		if p.MatchToken(lexer.LT) {
+			names, namesErr := p.parseTypeParameters()
+
+			if namesErr != nil {
+				return nil, namesErr
+			}
+
+			typeParameters = names
+		}
+
+		_, leftParenErr := p.Consume(lexer.L_PAREN, "Expected '('")
 
 		if leftParenErr != nil {
 			return nil, leftParenErr
 		}
 
-		line := leftParen.Line
-
 		// if there's a right parenthesis, that means the function doesn't have any parameters.
 		if !p.MatchToken(lexer.R_PAREN) {
 			for {
@@ -171,40 +499,46 @@ func (p *Parser) parseFunction() (ast.Expression, error) {
 					break
 				}
 
+				mutable := p.MatchToken(lexer.MUT)
 				parameter, parameterErr := p.Consume(lexer.IDENTIFIER, "Expected parameter name.")
 
 				if parameterErr != nil {
 					return nil, parameterErr
 				}
 
-				_, colonErr := p.Consume(lexer.COLON, "Expected ':' before type.")
+				// parameters without a type are inferred, i.e. fn(x) => x + 1
+				var parameterType ast.Type
 
-				if colonErr != nil {
-					return nil, colonErr
-				}
+				if p.MatchToken(lexer.COLON) {
+					declaredType, parameterTypeErr := p.parseTypeDeclaration()
 
-				parameterType, parameterTypeErr := p.parseTypeDeclaration()
+					if parameterTypeErr != nil {
+						return nil, parameterTypeErr
+					}
 
-				if parameterTypeErr != nil {
-					return nil, parameterTypeErr
+					parameterType = declaredType
 				}
 
 				parameters = append(parameters, ast.VariableDeclaration{
-					Name: parameter.Literal, Type: parameterType, Value: nil,
+					Name:         parameter.Literal,
+					Type:         parameterType,
+					Value:        nil,
+					Mutable:      mutable,
+					NodeMetadata: ast.CreateMetadata(p.spanFrom(parameter)),
 				})
 			}
 		}
 
-		_, colonErr := p.Consume(lexer.COLON, "Expected ':' before function return type.")
+		var returnType ast.Type
 
-		if colonErr != nil {
-			return nil, colonErr
-		}
+		if p.MatchToken(lexer.COLON) {
+			declaredType, returnTypeErr := p.parseTypeDeclaration()
 
-		returnType, returnTypeErr := p.parseTypeDeclaration()
+			if returnTypeErr != nil {
+				return nil, returnTypeErr
+			}
 
-		if returnTypeErr != nil {
-			return nil, returnTypeErr
+			returnType = declaredType
 		}
 
 		_, thickArrowErr := p.Consume(lexer.THICK_ARROW, "Expected '=>' after parameter defintion.")
@@ -213,17 +547,18 @@ func (p *Parser) parseFunction() (ast.Expression, error) {
 			return nil, thickArrowErr
 		}
 
-		body, statErr := p.parseStatement()
+		body, statErr := p.parseBody("Expected body of function.")
 
 		if statErr != nil {
 			return nil, statErr
 		}
 
 		return &ast.FunctionExpression{
-			Parameters:   parameters,
-			Body:         body,
-			ReturnType:   returnType,
-			NodeMetadata: ast.CreateMetadata(line),
+			TypeParameters: typeParameters,
+			Parameters:     parameters,
+			Body:           body,
+			ReturnType:     returnType,
+			NodeMetadata:   ast.CreateMetadata(p.spanFrom(start)),
 		}, nil
 	}
 
@@ -242,7 +577,7 @@ func (p *Parser) parseUnary() (ast.Expression, error) {
 		return &ast.Unary{
 			Value:        expr,
 			Operator:     op.Type,
-			NodeMetadata: ast.CreateMetadata(op.Line),
+			NodeMetadata: ast.CreateMetadata(op.Span.To(expr.GetSpan())),
 		}, nil
 	}
 
@@ -268,7 +603,7 @@ func (p *Parser) parseFactor() (ast.Expression, error) {
 			Left:         expr,
 			Right:        rightExpr,
 			Operator:     op.Type,
-			NodeMetadata: ast.CreateMetadata(op.Line),
+			NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(rightExpr.GetSpan())),
 		}
 	}
 
@@ -294,7 +629,7 @@ func (p *Parser) parseTerm() (ast.Expression, error) {
 			Left:         expr,
 			Right:        rightExpr,
 			Operator:     op.Type,
-			NodeMetadata: ast.CreateMetadata(op.Line),
+			NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(rightExpr.GetSpan())),
 		}
 	}
 
@@ -320,7 +655,7 @@ func (p *Parser) parseComparison() (ast.Expression, error) {
 			Left:         expr,
 			Right:        rightExpr,
 			Operator:     op.Type,
-			NodeMetadata: ast.CreateMetadata(op.Line),
+			NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(rightExpr.GetSpan())),
 		}
 	}
 
@@ -346,7 +681,7 @@ func (p *Parser) parseEquality() (ast.Expression, error) {
 			Left:         expr,
 			Right:        rightExpr,
 			Operator:     op.Type,
-			NodeMetadata: ast.CreateMetadata(op.Line),
+			NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(rightExpr.GetSpan())),
 		}
 	}
 
@@ -372,7 +707,7 @@ func (p *Parser) parseLogicalAnd() (ast.Expression, error) {
 			Left:         expr,
 			Right:        right,
 			Operator:     op.Type,
-			NodeMetadata: ast.CreateMetadata(op.Line),
+			NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(right.GetSpan())),
 		}
 	}
 
@@ -398,13 +733,48 @@ func (p *Parser) parseLogicalOr() (ast.Expression, error) {
 			Left:         expr,
 			Right:        right,
 			Operator:     op.Type,
-			NodeMetadata: ast.CreateMetadata(op.Line),
+			NodeMetadata: ast.CreateMetadata(expr.GetSpan().To(right.GetSpan())),
 		}
 	}
 
 	return expr, nil
 }
 
+/*
+Assignment has the lowest precedence and is right associative, so
+'a = b = c' assigns 'c' to 'b' and then to 'a'.
+*/
+func (p *Parser) parseAssignment() (ast.Expression, error) {
+	target, targetErr := p.parseLogicalOr()
+
+	if targetErr != nil {
+		return nil, targetErr
+	}
+
+	if p.MatchToken(lexer.EQUAL, lexer.ADD_EQUAL, lexer.SUB_EQUAL, lexer.MULT_EQUAL, lexer.DIV_EQUAL) {
+		op := p.PreviousToken()
+		value, valueErr := p.parseAssignment()
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		switch target.(type) {
+		case *ast.VariableExpression, *ast.GetExpression, *ast.IndexExpression:
+			return &ast.Assignment{
+				Target:       target,
+				Value:        value,
+				Operator:     op.Type,
+				NodeMetadata: ast.CreateMetadata(target.GetSpan().To(value.GetSpan())),
+			}, nil
+		}
+
+		return nil, CreateParseError(diagnostics.InvalidSyntaxCode, target.GetSpan(), "Invalid assignment target.")
+	}
+
+	return target, nil
+}
+
 func (p *Parser) parseExpression() (ast.Expression, error) {
-	return p.parseLogicalOr()
+	return p.parseAssignment()
 }
@@ -1,8 +1,11 @@
 package parser
 
 import (
-	"github.com/fatih/color"
+	"fmt"
+
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
 	"github.com/gmisail/glamlang/lexer"
 )
 
@@ -10,10 +13,42 @@ type Parser struct {
 	current int
 	Lexer   *lexer.Lexer
 	Tokens  []lexer.Token
+	Errors  []*ParseError
+
+	// set while parsing the value of a match expression, where '{' begins the arms
+	noRecordInstance bool
 }
 
 func (p *Parser) AdvanceToken() {
-	p.current += 1
+	// stay on the END_OF_FILE token so that it can be reported
+	if !p.isAtEnd() {
+		p.current += 1
+	}
+}
+
+/*
+Returns true once every token before END_OF_FILE has been consumed.
+*/
+func (p *Parser) isAtEnd() bool {
+	current := p.CurrentToken()
+
+	return current == nil || current.Type == lexer.END_OF_FILE
+}
+
+/*
+Returns an empty span just after the last token, which is where input
+was expected once the file ends early.
+*/
+func (p *Parser) endOfInput() io.Span {
+	if previous := p.PreviousToken(); previous != nil {
+		return io.Span{Start: previous.Span.End, End: previous.Span.End}
+	}
+
+	if current := p.CurrentToken(); current != nil {
+		return current.Span
+	}
+
+	return io.Span{}
 }
 
 func (p *Parser) CurrentToken() *lexer.Token {
@@ -40,6 +75,20 @@ func (p *Parser) PeekToken() *lexer.Token {
 	return nil
 }
 
+/*
+Returns the span from the start token up to and including the last token
+that was consumed.
+*/
+func (p *Parser) spanFrom(start *lexer.Token) io.Span {
+	end := p.PreviousToken()
+
+	if end == nil {
+		return start.Span
+	}
+
+	return start.Span.To(end.Span)
+}
+
 func (p *Parser) MatchToken(types ...lexer.TokenType) bool {
 	next := p.CurrentToken()
 
@@ -57,62 +106,173 @@ func (p *Parser) MatchToken(types ...lexer.TokenType) bool {
 
 func (p *Parser) Check(types ...lexer.TokenType) bool {
 	next := p.PeekToken()
-	isNext := false
+
+	if next == nil {
+		return false
+	}
 
 	for _, token := range types {
 		if next.Type == token {
-			isNext = true
-			break
+			return true
 		}
 	}
 
-	return next != nil && isNext
+	return false
+}
+
+/*
+Returns true if the current token is one of the given types without consuming it.
+*/
+func (p *Parser) CheckCurrent(types ...lexer.TokenType) bool {
+	current := p.CurrentToken()
+
+	if current == nil {
+		return false
+	}
+
+	for _, tokenType := range types {
+		if current.Type == tokenType {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (p *Parser) Consume(tokenType lexer.TokenType, message string) (*lexer.Token, error) {
 	if !p.MatchToken(tokenType) {
-		currentToken := p.CurrentToken()
+		// the lexer has already reported the invalid input, so a second error would be noise
+		if p.CheckCurrent(lexer.ERROR) {
+			return nil, errInvalidToken
+		}
 
-		if currentToken != nil {
-			return nil, &ParseError{message: message, line: currentToken.Line}
+		if p.isAtEnd() {
+			return nil, CreateParseError(diagnostics.UnexpectedEndCode, p.endOfInput(), fmt.Sprintf("Unexpected end of input. %s", message))
 		}
 
-		return nil, &ParseError{message: message, line: 0}
+		return nil, CreateParseError(diagnostics.UnexpectedTokenCode, p.CurrentToken().Span, message)
 	}
 
 	return p.PreviousToken(), nil
 }
 
-func (p *Parser) Calibrate() {
-	p.AdvanceToken()
+func (p *Parser) addError(err error) {
+	if parseErr, ok := err.(*ParseError); ok {
+		p.Errors = append(p.Errors, parseErr)
+	}
+}
+
+/*
+Skips tokens until the current token can begin a new statement or closes
+the enclosing block. Outside of braces a new line begins a new statement,
+so expressions and assignments after the malformed statement are still
+parsed. Braces left open by the malformed statement, i.e. a record
+instance, are skipped over so that they don't end the block early. A
+statement keyword at the start of a line always begins a new statement,
+otherwise a brace that is never closed would skip the rest of the file.
+*/
+func (p *Parser) synchronize(start int) {
+	depth := 0
+
+	for i := start; i < p.current && i < len(p.Tokens); i++ {
+		switch p.Tokens[i].Type {
+		case lexer.L_BRACE:
+			depth++
+		case lexer.R_BRACE:
+			depth--
+		}
+	}
+
+	for !p.isAtEnd() {
+		if depth <= 0 && p.startsLine() {
+			return
+		}
+
+		switch p.CurrentToken().Type {
+		case lexer.L_BRACE:
+			if depth <= 0 {
+				return
+			}
+
+			depth++
+		case lexer.R_BRACE:
+			if depth <= 0 {
+				return
+			}
+
+			depth--
+		case lexer.LET, lexer.TYPE, lexer.IF, lexer.WHILE, lexer.FOR, lexer.RETURN, lexer.BREAK, lexer.CONTINUE:
+			if depth <= 0 || p.startsLine() {
+				return
+			}
+		}
 
-	for p.PeekToken() != nil && !p.Check(lexer.WHILE, lexer.IF, lexer.WHILE, lexer.LET, lexer.TYPE, lexer.L_BRACE) {
 		p.AdvanceToken()
 	}
 }
 
-func Parse(lexer *lexer.Lexer, tokens []lexer.Token) (bool, []ast.Statement) {
-	parser := &Parser{current: 0, Lexer: lexer, Tokens: tokens}
+/*
+Returns true if the current token is the first one on its line.
+*/
+func (p *Parser) startsLine() bool {
+	previous := p.PreviousToken()
+
+	return previous == nil || previous.Span.End.Line < p.CurrentToken().Span.Start.Line
+}
+
+/*
+Parses a declaration, or records the syntax error and skips to the next
+statement if it is malformed. Skipped tokens are replaced by a BadStatement
+so that the rest of the program can still be checked. Returns nil once
+there are no tokens left.
+*/
+func (p *Parser) parseRecoverable() ast.Statement {
+	start := p.current
+	statement, err := p.parseDeclaration()
+
+	if err == nil {
+		return statement
+	}
+
+	p.addError(err)
+
+	// always make progress, otherwise an unexpected token would be parsed forever
+	if p.current == start {
+		p.AdvanceToken()
+	}
+
+	p.synchronize(start)
+
+	return &ast.BadStatement{
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(&p.Tokens[start])),
+	}
+}
+
+/*
+Parses every statement in the token stream, collecting syntax errors
+rather than stopping at the first one.
+*/
+func ParseProgram(lexer *lexer.Lexer, tokens []lexer.Token) ([]ast.Statement, []*ParseError) {
+	parser := &Parser{current: 0, Lexer: lexer, Tokens: tokens, Errors: make([]*ParseError, 0)}
 	statements := make([]ast.Statement, 0)
-	isValid := true
 
 	for {
-		statement, err := parser.parseDeclaration()
+		statement := parser.parseRecoverable()
 
 		// no more statements
-		if statement == nil && err == nil {
+		if statement == nil {
 			break
-		} else if statement == nil && err != nil {
-			isValid = false
-
-			color.Red(err.Error())
-			parser.Calibrate()
-
-			continue
 		}
 
 		statements = append(statements, statement)
 	}
 
-	return isValid, statements
+	return statements, parser.Errors
+}
+
+func Parse(lexer *lexer.Lexer, tokens []lexer.Token) (bool, []ast.Statement) {
+	statements, errors := ParseProgram(lexer, tokens)
+
+	// statements containing invalid tokens are skipped without a ParseError
+	return len(errors) == 0 && (lexer == nil || len(lexer.Errors) == 0), statements
 }
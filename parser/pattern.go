@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/lexer"
+)
+
+func (p *Parser) parsePattern() (ast.Pattern, error) {
+	start := p.CurrentToken()
+
+	// (<pattern>, <pattern>, ...)
+	if p.MatchToken(lexer.L_PAREN) {
+		elements := make([]ast.Pattern, 0)
+
+		for hasComma := true; hasComma; hasComma = p.MatchToken(lexer.COMMA) {
+			element, elementErr := p.parsePattern()
+
+			if elementErr != nil {
+				return nil, elementErr
+			}
+
+			elements = append(elements, element)
+		}
+
+		_, rightParenErr := p.Consume(lexer.R_PAREN, "Expected ')' after tuple pattern.")
+
+		if rightParenErr != nil {
+			return nil, rightParenErr
+		}
+
+		return &ast.TuplePattern{Elements: elements, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+	}
+
+	// { <name> (: <pattern>)?, ... }
+	if p.MatchToken(lexer.L_BRACE) {
+		fields, fieldsErr := p.parseRecordPatternFields()
+
+		if fieldsErr != nil {
+			return nil, fieldsErr
+		}
+
+		return &ast.RecordPattern{Fields: fields, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+	}
+
+	// [<pattern>, ..., ..<rest>]
+	if p.MatchToken(lexer.L_BRACKET) {
+		return p.parseArrayPattern(start)
+	}
+
+	if p.CheckCurrent(lexer.INT, lexer.FLOAT, lexer.STRING, lexer.TRUE, lexer.FALSE, lexer.NULL, lexer.SUB) {
+		return p.parseLiteralPattern()
+	}
+
+	name, nameErr := p.Consume(lexer.IDENTIFIER, "Expected name or pattern.")
+
+	if nameErr != nil {
+		return nil, nameErr
+	}
+
+	if name.Literal == "_" {
+		return &ast.WildcardPattern{NodeMetadata: ast.CreateMetadata(name.Span)}, nil
+	}
+
+	// <variant> { <name> (: <pattern>)?, ... }
+	if p.MatchToken(lexer.L_BRACE) {
+		fields, fieldsErr := p.parseRecordPatternFields()
+
+		if fieldsErr != nil {
+			return nil, fieldsErr
+		}
+
+		return &ast.VariantPattern{
+			Name:         name.Literal,
+			Fields:       fields,
+			NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
+		}, nil
+	}
+
+	return &ast.BindingPattern{Name: name.Literal, NodeMetadata: ast.CreateMetadata(name.Span)}, nil
+}
+
+/*
+Parses the fields of a record or variant pattern after the opening '{'.
+*/
+func (p *Parser) parseRecordPatternFields() ([]ast.RecordPatternField, error) {
+	fields := make([]ast.RecordPatternField, 0)
+
+	for hasComma := true; hasComma && !p.CheckCurrent(lexer.R_BRACE); hasComma = p.MatchToken(lexer.COMMA) {
+		field, fieldErr := p.Consume(lexer.IDENTIFIER, "Expected field name in record pattern.")
+
+		if fieldErr != nil {
+			return nil, fieldErr
+		}
+
+		var fieldPattern ast.Pattern = &ast.BindingPattern{
+			Name:         field.Literal,
+			NodeMetadata: ast.CreateMetadata(field.Span),
+		}
+
+		if p.MatchToken(lexer.COLON) {
+			renamed, renamedErr := p.parsePattern()
+
+			if renamedErr != nil {
+				return nil, renamedErr
+			}
+
+			fieldPattern = renamed
+		}
+
+		fields = append(fields, ast.RecordPatternField{
+			Name:    field.Literal,
+			Pattern: fieldPattern,
+			Span:    p.spanFrom(field),
+		})
+	}
+
+	_, rightBraceErr := p.Consume(lexer.R_BRACE, "Expected '}' after record pattern.")
+
+	if rightBraceErr != nil {
+		return nil, rightBraceErr
+	}
+
+	return fields, nil
+}
+
+/*
+Parses an array pattern after the opening '['. The rest pattern, if any,
+must come last and may omit its name, i.e. [first, ..].
+*/
+func (p *Parser) parseArrayPattern(start *lexer.Token) (ast.Pattern, error) {
+	elements := make([]ast.Pattern, 0)
+	var rest ast.Pattern
+
+	for hasComma := true; hasComma && !p.CheckCurrent(lexer.R_BRACKET); hasComma = p.MatchToken(lexer.COMMA) {
+		if rest != nil {
+			return nil, CreateParseError(diagnostics.InvalidSyntaxCode, p.CurrentToken().Span, "Rest pattern must be the last element of an array pattern.")
+		}
+
+		if p.MatchToken(lexer.RANGE) {
+			rangeToken := p.PreviousToken()
+			rest = &ast.WildcardPattern{NodeMetadata: ast.CreateMetadata(rangeToken.Span)}
+
+			if p.CheckCurrent(lexer.IDENTIFIER) {
+				restPattern, restErr := p.parsePattern()
+
+				if restErr != nil {
+					return nil, restErr
+				}
+
+				rest = restPattern
+			}
+
+			continue
+		}
+
+		element, elementErr := p.parsePattern()
+
+		if elementErr != nil {
+			return nil, elementErr
+		}
+
+		elements = append(elements, element)
+	}
+
+	_, rightBracketErr := p.Consume(lexer.R_BRACKET, "Expected ']' after array pattern.")
+
+	if rightBracketErr != nil {
+		return nil, rightBracketErr
+	}
+
+	return &ast.ArrayPattern{
+		Elements:     elements,
+		Rest:         rest,
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
+	}, nil
+}
+
+/*
+Parses a literal pattern. Numbers may be negated, i.e. -1.
+*/
+func (p *Parser) parseLiteralPattern() (ast.Pattern, error) {
+	start := p.CurrentToken()
+	negated := p.MatchToken(lexer.SUB)
+
+	if negated && !p.CheckCurrent(lexer.INT, lexer.FLOAT) {
+		return nil, CreateParseError(diagnostics.UnexpectedTokenCode, p.spanFrom(start), "Expected number after '-' in pattern.")
+	}
+
+	p.AdvanceToken()
+
+	literal := p.PreviousToken()
+	value := literal.Value
+	literalType := literal.Type
+
+	switch literal.Type {
+	case lexer.INT:
+		if negated {
+			value = -literal.Value.(int64)
+		}
+	case lexer.FLOAT:
+		if negated {
+			value = -literal.Value.(float64)
+		}
+	case lexer.TRUE, lexer.FALSE:
+		value = literal.Type == lexer.TRUE
+		literalType = lexer.BOOL
+	case lexer.NULL:
+		value = nil
+	}
+
+	return &ast.LiteralPattern{
+		Value:        value,
+		LiteralType:  literalType,
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
+	}, nil
+}
@@ -1,31 +1,43 @@
 package parser
 
 import (
+	"fmt"
+
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
 	"github.com/gmisail/glamlang/lexer"
 )
 
 func (p *Parser) parseVariableDeclaration() (ast.Statement, error) {
 	/*
-		let <name> : <type> (= <expression>)?
+		let (mut)? <name> (: <type>)? (= <expression>)?
+
+		the type can only be left out if there's a value to infer it from
 	*/
 
+	start := p.PreviousToken()
+	mutable := p.MatchToken(lexer.MUT)
+
+	if p.CheckCurrent(lexer.L_PAREN, lexer.L_BRACE) {
+		return p.parseDestructuringDeclaration(start, mutable)
+	}
+
 	name, nameErr := p.Consume(lexer.IDENTIFIER, "Expected variable name.")
 
 	if nameErr != nil {
 		return nil, nameErr
 	}
 
-	_, colonErr := p.Consume(lexer.COLON, "Expected ':' after variable name in declaration.")
+	var variableType ast.Type
 
-	if colonErr != nil {
-		return nil, colonErr
-	}
+	if p.MatchToken(lexer.COLON) {
+		declaredType, typeErr := p.parseTypeDeclaration()
 
-	variableType, typeErr := p.parseTypeDeclaration()
+		if typeErr != nil {
+			return nil, typeErr
+		}
 
-	if typeErr != nil {
-		return nil, typeErr
+		variableType = declaredType
 	}
 
 	var value ast.Expression = nil
@@ -37,30 +49,79 @@ func (p *Parser) parseVariableDeclaration() (ast.Statement, error) {
 		if exprErr != nil {
 			return nil, exprErr
 		}
+	} else if variableType == nil {
+		_, equalErr := p.Consume(lexer.EQUAL, "Expected ':' or '=' after variable name in declaration.")
+
+		return nil, equalErr
 	}
 
 	return &ast.VariableDeclaration{
 		Name:         name.Literal,
 		Type:         variableType,
 		Value:        value,
-		NodeMetadata: ast.CreateMetadata(name.Line),
+		Mutable:      mutable,
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
+	}, nil
+}
+
+/*
+let (mut)? <pattern> (: <type>)? = <expression>
+*/
+func (p *Parser) parseDestructuringDeclaration(start *lexer.Token, mutable bool) (ast.Statement, error) {
+	pattern, patternErr := p.parsePattern()
+
+	if patternErr != nil {
+		return nil, patternErr
+	}
+
+	var patternType ast.Type
+
+	if p.MatchToken(lexer.COLON) {
+		declaredType, typeErr := p.parseTypeDeclaration()
+
+		if typeErr != nil {
+			return nil, typeErr
+		}
+
+		patternType = declaredType
+	}
+
+	_, equalErr := p.Consume(lexer.EQUAL, "Expected '=' since a destructuring declaration must have a value.")
+
+	if equalErr != nil {
+		return nil, equalErr
+	}
+
+	value, valueErr := p.parseExpression()
+
+	if valueErr != nil {
+		return nil, valueErr
+	}
+
+	return &ast.DestructuringDeclaration{
+		Pattern:      pattern,
+		Type:         patternType,
+		Value:        value,
+		Mutable:      mutable,
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
 	}, nil
 }
 
 func (p *Parser) parseRecord() (ast.Type, error) {
-	_, leftBraceErr := p.Consume(lexer.L_BRACE, "Expected '{' when declaring record.")
+	leftBrace, leftBraceErr := p.Consume(lexer.L_BRACE, "Expected '{' when declaring record.")
 
 	if leftBraceErr != nil {
 		return nil, leftBraceErr
 	}
 
 	if p.MatchToken(lexer.R_BRACE) {
-		return &ast.RecordType{Fields: make(map[string]ast.Type)}, nil
+		return &ast.RecordType{Fields: make(map[string]ast.Type), Span: p.spanFrom(leftBrace)}, nil
 	}
 
 	fields := make(map[string]ast.Type)
 
-	for hasComma := true; hasComma; hasComma = p.MatchToken(lexer.COMMA) {
+	// fields can be separated by commas or simply placed on their own lines
+	for hasField := true; hasField; hasField = p.MatchToken(lexer.COMMA) || p.CheckCurrent(lexer.IDENTIFIER) {
 		variableName, variableErr := p.Consume(lexer.IDENTIFIER, "Expected variable name")
 
 		if variableErr != nil {
@@ -88,10 +149,11 @@ func (p *Parser) parseRecord() (ast.Type, error) {
 		return nil, rightBraceErr
 	}
 
-	return &ast.RecordType{Fields: fields}, nil
+	return &ast.RecordType{Fields: fields, Span: p.spanFrom(leftBrace)}, nil
 }
 
 func (p *Parser) parseRecordDeclaration() (ast.Statement, error) {
+	start := p.PreviousToken()
 	identifier, identifierErr := p.Consume(
 		lexer.IDENTIFIER,
 		"Expected name after type definition.",
@@ -101,6 +163,26 @@ func (p *Parser) parseRecordDeclaration() (ast.Statement, error) {
 		return nil, identifierErr
 	}
 
+	var typeParameters []string
+
+	if p.MatchToken(lexer.LT) {
+		parameters, parametersErr := p.parseTypeParameters()
+
+		if parametersErr != nil {
+			return nil, parametersErr
+		}
+
+		typeParameters = parameters
+	}
+
+	if p.MatchToken(lexer.EQUAL) {
+		if typeParameters != nil {
+			return nil, CreateParseError(diagnostics.InvalidSyntaxCode, p.spanFrom(start), "Sum types cannot have type parameters.")
+		}
+
+		return p.parseSumTypeDeclaration(start, identifier)
+	}
+
 	var inheritsFrom string
 
 	if p.MatchToken(lexer.L_PAREN) {
@@ -128,14 +210,59 @@ func (p *Parser) parseRecordDeclaration() (ast.Statement, error) {
 	recordValue, isRecord := record.(*ast.RecordType)
 
 	if !isRecord {
-		return nil, CreateParseError(identifier.Line, "Expected record declaration.")
+		return nil, CreateParseError(diagnostics.UnexpectedTokenCode, identifier.Span, "Expected record declaration.")
 	}
 
 	return &ast.RecordDeclaration{
-		Name:         identifier.Literal,
-		Record:       *recordValue,
-		Inherits:     inheritsFrom,
-		NodeMetadata: ast.CreateMetadata(identifier.Line),
+		Name:           identifier.Literal,
+		TypeParameters: typeParameters,
+		Record:         *recordValue,
+		Inherits:       inheritsFrom,
+		NodeMetadata:   ast.CreateMetadata(p.spanFrom(start)),
+	}, nil
+}
+
+/*
+Parses the variants of a sum type, i.e. Circle { r: float } | Empty. A
+variant only carries fields if it is followed by a record.
+*/
+func (p *Parser) parseSumTypeDeclaration(start *lexer.Token, name *lexer.Token) (ast.Statement, error) {
+	variants := make([]*ast.Variant, 0)
+
+	// like OCaml, the first variant may be preceded by a '|'
+	p.MatchToken(lexer.PIPE)
+
+	for hasVariant := true; hasVariant; hasVariant = p.MatchToken(lexer.PIPE) {
+		variantName, variantErr := p.Consume(lexer.IDENTIFIER, "Expected variant name.")
+
+		if variantErr != nil {
+			return nil, variantErr
+		}
+
+		record := &ast.RecordType{Fields: make(map[string]ast.Type), Span: variantName.Span}
+
+		if p.CheckCurrent(lexer.L_BRACE) {
+			fields, fieldsErr := p.parseRecord()
+
+			if fieldsErr != nil {
+				return nil, fieldsErr
+			}
+
+			record = fields.(*ast.RecordType)
+		}
+
+		variants = append(variants, &ast.Variant{
+			Name:   variantName.Literal,
+			Record: *record,
+			Span:   p.spanFrom(variantName),
+		})
+	}
+
+	span := p.spanFrom(start)
+
+	return &ast.SumTypeDeclaration{
+		Sum:          &ast.SumType{Name: name.Literal, Variants: variants, Span: span},
+		NodeMetadata: ast.CreateMetadata(span),
 	}, nil
 }
 
@@ -152,34 +279,31 @@ func (p *Parser) parseBlockStatement() (ast.Statement, error) {
 
 	openParen := p.PreviousToken()
 
-	for p.CurrentToken().Type != lexer.R_BRACE /*&& is not at end */ {
-		statement, statementErr := p.parseDeclaration()
+	// errors inside of the block are recovered from here so that one bad statement
+	// doesn't discard the rest of the block
+	for !p.isAtEnd() && !p.CheckCurrent(lexer.R_BRACE) {
+		statement := p.parseRecoverable()
 
 		if statement == nil {
 			break
 		}
 
-		if statementErr != nil {
-			return nil, statementErr
-		}
-
 		statements = append(statements, statement)
 	}
 
-	_, rightBraceErr := p.Consume(lexer.R_BRACE, "Expected '}' after block")
-
-	if rightBraceErr != nil {
-		return nil, rightBraceErr
+	// only reachable at the end of the file, so keep what was parsed
+	if _, rightBraceErr := p.Consume(lexer.R_BRACE, "Expected '}' after block"); rightBraceErr != nil {
+		p.addError(rightBraceErr)
 	}
 
 	return &ast.BlockStatement{
 		Statements:   statements,
-		NodeMetadata: ast.CreateMetadata(openParen.Line),
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(openParen)),
 	}, nil
 }
 
 func (p *Parser) parseIfStatement() (ast.Statement, error) {
-	line := p.PreviousToken().Line
+	start := p.PreviousToken()
 
 	_, openParenErr := p.Consume(lexer.L_PAREN, "Expected open parenthesis.")
 
@@ -199,7 +323,7 @@ func (p *Parser) parseIfStatement() (ast.Statement, error) {
 		return nil, closeParenErr
 	}
 
-	ifBranch, ifBranchErr := p.parseStatement()
+	ifBranch, ifBranchErr := p.parseBody("Expected body of if statement.")
 
 	if ifBranchErr != nil {
 		return nil, ifBranchErr
@@ -208,7 +332,7 @@ func (p *Parser) parseIfStatement() (ast.Statement, error) {
 	var elseBranch ast.Statement = nil
 
 	if p.MatchToken(lexer.ELSE) {
-		elseBranchStatement, elseBranchStatementErr := p.parseStatement()
+		elseBranchStatement, elseBranchStatementErr := p.parseBody("Expected body of else statement.")
 
 		if elseBranchStatementErr != nil {
 			return nil, elseBranchStatementErr
@@ -221,12 +345,12 @@ func (p *Parser) parseIfStatement() (ast.Statement, error) {
 		Condition:    condition,
 		Body:         ifBranch,
 		ElseBody:     elseBranch,
-		NodeMetadata: ast.CreateMetadata(line),
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
 	}, nil
 }
 
 func (p *Parser) parseWhileStatement() (ast.Statement, error) {
-	line := p.PreviousToken().Line
+	start := p.PreviousToken()
 
 	_, openParenErr := p.Consume(lexer.L_PAREN, "Expected open parenthesis.")
 
@@ -246,7 +370,7 @@ func (p *Parser) parseWhileStatement() (ast.Statement, error) {
 		return nil, closeParenErr
 	}
 
-	body, bodyErr := p.parseStatement()
+	body, bodyErr := p.parseBody("Expected body of while loop.")
 
 	if bodyErr != nil {
 		return nil, bodyErr
@@ -255,47 +379,203 @@ func (p *Parser) parseWhileStatement() (ast.Statement, error) {
 	return &ast.WhileStatement{
 		Condition:    condition,
 		Body:         body,
-		NodeMetadata: ast.CreateMetadata(line),
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
+	}, nil
+}
+
+func (p *Parser) parseForStatement() (ast.Statement, error) {
+	/*
+		for (<name> in <expression>) <statement>
+		for (<name> in <expression>..(=)?<expression>) <statement>
+	*/
+
+	start := p.PreviousToken()
+
+	_, openParenErr := p.Consume(lexer.L_PAREN, "Expected open parenthesis.")
+
+	if openParenErr != nil {
+		return nil, openParenErr
+	}
+
+	variable, variableErr := p.Consume(lexer.IDENTIFIER, "Expected loop variable name.")
+
+	if variableErr != nil {
+		return nil, variableErr
+	}
+
+	_, inErr := p.Consume(lexer.IN, "Expected 'in' after loop variable.")
+
+	if inErr != nil {
+		return nil, inErr
+	}
+
+	iterable, iterableErr := p.parseExpression()
+
+	if iterableErr != nil {
+		return nil, iterableErr
+	}
+
+	if p.MatchToken(lexer.RANGE, lexer.RANGE_INCLUSIVE) {
+		inclusive := p.PreviousToken().Type == lexer.RANGE_INCLUSIVE
+		end, endErr := p.parseExpression()
+
+		if endErr != nil {
+			return nil, endErr
+		}
+
+		iterable = &ast.RangeExpression{
+			Start:        iterable,
+			End:          end,
+			Inclusive:    inclusive,
+			NodeMetadata: ast.CreateMetadata(iterable.GetSpan().To(end.GetSpan())),
+		}
+	}
+
+	_, closeParenErr := p.Consume(lexer.R_PAREN, "Expected closing parenthesis.")
+
+	if closeParenErr != nil {
+		return nil, closeParenErr
+	}
+
+	body, bodyErr := p.parseBody("Expected body of for loop.")
+
+	if bodyErr != nil {
+		return nil, bodyErr
+	}
+
+	return &ast.ForStatement{
+		Variable:     variable.Literal,
+		VariableSpan: variable.Span,
+		Iterable:     iterable,
+		Body:         body,
+		NodeMetadata: ast.CreateMetadata(p.spanFrom(start)),
 	}, nil
 }
 
 func (p *Parser) parseExpressionStatement() (ast.Statement, error) {
-	line := p.CurrentToken().Line
 	expression, err := p.parseExpression()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &ast.ExpressionStatement{Value: expression, NodeMetadata: ast.CreateMetadata(line)}, err
+	return &ast.ExpressionStatement{Value: expression, NodeMetadata: ast.CreateMetadata(expression.GetSpan())}, err
 }
 
 func (p *Parser) parseReturnStatement() (ast.Statement, error) {
-	line := p.PreviousToken().Line
+	start := p.PreviousToken()
 	value, valueErr := p.parseExpression()
 
 	if valueErr != nil {
 		return nil, valueErr
 	}
 
-	return &ast.ReturnStatement{Value: value, NodeMetadata: ast.CreateMetadata(line)}, nil
+	return &ast.ReturnStatement{Value: value, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+}
+
+/*
+Parses a loop preceded by a label, i.e. 'outer: while (true) { ... }'.
+*/
+func (p *Parser) parseLabeledLoop() (ast.Statement, error) {
+	label := p.CurrentToken()
+
+	p.AdvanceToken()
+	p.AdvanceToken()
+
+	if p.MatchToken(lexer.WHILE) {
+		loop, err := p.parseWhileStatement()
+
+		if err != nil {
+			return nil, err
+		}
+
+		whileStat := loop.(*ast.WhileStatement)
+		whileStat.Label = label.Literal
+		whileStat.Span = label.Span.To(whileStat.Span)
+
+		return whileStat, nil
+	} else if p.MatchToken(lexer.FOR) {
+		loop, err := p.parseForStatement()
+
+		if err != nil {
+			return nil, err
+		}
+
+		forStat := loop.(*ast.ForStatement)
+		forStat.Label = label.Literal
+		forStat.Span = label.Span.To(forStat.Span)
+
+		return forStat, nil
+	}
+
+	return nil, CreateParseError(diagnostics.InvalidSyntaxCode, label.Span, "Only 'while' and 'for' loops can be labeled.")
+}
+
+/*
+Parses the optional label after 'break' or 'continue'. It must be on the
+same line, otherwise it is the start of the next statement.
+*/
+func (p *Parser) parseJumpLabel(keyword *lexer.Token) string {
+	if p.CheckCurrent(lexer.IDENTIFIER) && p.CurrentToken().Line == keyword.Line {
+		p.AdvanceToken()
+
+		return p.PreviousToken().Literal
+	}
+
+	return ""
+}
+
+func (p *Parser) parseBreakStatement() (ast.Statement, error) {
+	start := p.PreviousToken()
+	label := p.parseJumpLabel(start)
+
+	return &ast.BreakStatement{Label: label, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+}
+
+func (p *Parser) parseContinueStatement() (ast.Statement, error) {
+	start := p.PreviousToken()
+	label := p.parseJumpLabel(start)
+
+	return &ast.ContinueStatement{Label: label, NodeMetadata: ast.CreateMetadata(p.spanFrom(start))}, nil
+}
+
+/*
+Parses the statement that makes up the body of an if, loop or function. Unlike
+parseStatement, running out of input is an error since the body is required.
+*/
+func (p *Parser) parseBody(message string) (ast.Statement, error) {
+	if p.isAtEnd() {
+		return nil, CreateParseError(diagnostics.UnexpectedEndCode, p.endOfInput(), fmt.Sprintf("Unexpected end of input. %s", message))
+	}
+
+	return p.parseStatement()
 }
 
 func (p *Parser) parseStatement() (ast.Statement, error) {
-	if p.CurrentToken() == nil {
+	if p.isAtEnd() {
 		return nil, nil
 	}
 
+	if p.CheckCurrent(lexer.IDENTIFIER) && p.Check(lexer.COLON) {
+		return p.parseLabeledLoop()
+	}
+
 	if p.MatchToken(lexer.L_BRACE) {
 		return p.parseBlockStatement()
 	} else if p.MatchToken(lexer.IF) {
 		return p.parseIfStatement()
 	} else if p.MatchToken(lexer.WHILE) {
 		return p.parseWhileStatement()
+	} else if p.MatchToken(lexer.FOR) {
+		return p.parseForStatement()
 	} else if p.MatchToken(lexer.TYPE) {
 		return p.parseRecordDeclaration()
 	} else if p.MatchToken(lexer.RETURN) {
 		return p.parseReturnStatement()
+	} else if p.MatchToken(lexer.BREAK) {
+		return p.parseBreakStatement()
+	} else if p.MatchToken(lexer.CONTINUE) {
+		return p.parseContinueStatement()
 	}
 
 	return p.parseExpressionStatement()
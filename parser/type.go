@@ -2,10 +2,44 @@ package parser
 
 import (
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
 	"github.com/gmisail/glamlang/lexer"
 )
 
 func (p *Parser) parseTypeDeclaration() (ast.Type, error) {
+	start := p.CurrentToken()
+
+	// generic function type, i.e. <T>(T) -> T
+	if p.MatchToken(lexer.LT) {
+		typeParameters, typeParametersErr := p.parseTypeParameters()
+
+		if typeParametersErr != nil {
+			return nil, typeParametersErr
+		}
+
+		if !p.CheckCurrent(lexer.L_PAREN) {
+			return nil, CreateParseError(diagnostics.UnexpectedTokenCode, p.spanFrom(start), "Expected function type after type parameters.")
+		}
+
+		functionType, functionErr := p.parseTypeDeclaration()
+
+		if functionErr != nil {
+			return nil, functionErr
+		}
+
+		genericType, isFunction := functionType.(*ast.FunctionType)
+
+		if !isFunction {
+			return nil, CreateParseError(diagnostics.InvalidSyntaxCode, functionType.GetSpan(), "Only function types can have type parameters.")
+		}
+
+		genericType.TypeParameters = typeParameters
+		genericType.Span = p.spanFrom(start)
+
+		return genericType, nil
+	}
+
+	// function type, i.e. (int, int) -> int, or tuple type, i.e. (int, string)
 	if p.MatchToken(lexer.L_PAREN) {
 		arguments := make([]ast.Type, 0)
 
@@ -23,15 +57,90 @@ func (p *Parser) parseTypeDeclaration() (ast.Type, error) {
 			arguments = append(arguments, argumentType)
 		}
 
+		if !p.CheckCurrent(lexer.ARROW) {
+			if len(arguments) > 1 {
+				isOptional := p.MatchToken(lexer.QUESTION)
+
+				return &ast.TupleType{Elements: arguments, Optional: isOptional, Span: p.spanFrom(start)}, nil
+			}
+
+			// a single type in parentheses is just that type, i.e. ((int) -> int) or ((int) -> int)?
+			if len(arguments) == 1 {
+				if p.MatchToken(lexer.QUESTION) {
+					return ast.MakeOptional(arguments[0]), nil
+				}
+
+				return arguments[0], nil
+			}
+		}
+
 		_, arrowErr := p.Consume(lexer.ARROW, "Expected '->' after argument type declaration.")
 
 		if arrowErr != nil {
 			return nil, arrowErr
 		}
 
-		returnType, _ := p.parseTypeDeclaration()
+		returnType, returnErr := p.parseTypeDeclaration()
 
-		return &ast.FunctionType{Parameters: arguments, ReturnType: returnType}, nil
+		if returnErr != nil {
+			return nil, returnErr
+		}
+
+		return &ast.FunctionType{Parameters: arguments, ReturnType: returnType, Span: p.spanFrom(start)}, nil
+	}
+
+	// {<type>: <type>}
+	if p.MatchToken(lexer.L_BRACE) {
+		keyType, keyErr := p.parseTypeDeclaration()
+
+		if keyErr != nil {
+			return nil, keyErr
+		}
+
+		_, colonErr := p.Consume(lexer.COLON, "Expected ':' after map key type.")
+
+		if colonErr != nil {
+			return nil, colonErr
+		}
+
+		valueType, valueErr := p.parseTypeDeclaration()
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		_, rightBraceErr := p.Consume(lexer.R_BRACE, "Expected '}' after map value type.")
+
+		if rightBraceErr != nil {
+			return nil, rightBraceErr
+		}
+
+		mapType := ast.CreateMapType(keyType, valueType)
+		mapType.Optional = p.MatchToken(lexer.QUESTION)
+		mapType.Span = p.spanFrom(start)
+
+		return mapType, nil
+	}
+
+	// [<type>]
+	if p.MatchToken(lexer.L_BRACKET) {
+		elementType, elementErr := p.parseTypeDeclaration()
+
+		if elementErr != nil {
+			return nil, elementErr
+		}
+
+		_, rightBracketErr := p.Consume(lexer.R_BRACKET, "Expected ']' after array element type.")
+
+		if rightBracketErr != nil {
+			return nil, rightBracketErr
+		}
+
+		arrayType := ast.CreateArrayType(elementType)
+		arrayType.Optional = p.MatchToken(lexer.QUESTION)
+		arrayType.Span = p.spanFrom(start)
+
+		return arrayType, nil
 	}
 
 	name, nameErr := p.Consume(lexer.IDENTIFIER, "Expected type name.")
@@ -40,7 +149,59 @@ func (p *Parser) parseTypeDeclaration() (ast.Type, error) {
 		return nil, nameErr
 	}
 
+	var arguments []ast.Type
+
+	// <type>, ...>
+	if p.MatchToken(lexer.LT) {
+		for hasComma := true; hasComma; hasComma = p.MatchToken(lexer.COMMA) {
+			argument, argumentErr := p.parseTypeDeclaration()
+
+			if argumentErr != nil {
+				return nil, argumentErr
+			}
+
+			arguments = append(arguments, argument)
+		}
+
+		_, closeErr := p.Consume(lexer.GT, "Expected '>' after type arguments.")
+
+		if closeErr != nil {
+			return nil, closeErr
+		}
+	}
+
 	isOptional := p.MatchToken(lexer.QUESTION)
 
-	return &ast.VariableType{Base: name.Literal, SubType: nil, Optional: isOptional}, nil
+	return &ast.VariableType{
+		Base:      name.Literal,
+		SubType:   nil,
+		Arguments: arguments,
+		Optional:  isOptional,
+		Span:      p.spanFrom(name),
+	}, nil
+}
+
+/*
+Parses the names of type parameters after the opening '<', i.e. <K, V>.
+*/
+func (p *Parser) parseTypeParameters() ([]string, error) {
+	names := make([]string, 0)
+
+	for hasComma := true; hasComma; hasComma = p.MatchToken(lexer.COMMA) {
+		name, nameErr := p.Consume(lexer.IDENTIFIER, "Expected name of type parameter.")
+
+		if nameErr != nil {
+			return nil, nameErr
+		}
+
+		names = append(names, name.Literal)
+	}
+
+	_, closeErr := p.Consume(lexer.GT, "Expected '>' after type parameters.")
+
+	if closeErr != nil {
+		return nil, closeErr
+	}
+
+	return names, nil
 }
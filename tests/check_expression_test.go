@@ -2,6 +2,7 @@ package tests
 
 import (
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
 	"github.com/gmisail/glamlang/lexer"
 	"github.com/gmisail/glamlang/parser"
 	"github.com/gmisail/glamlang/typechecker"
@@ -10,14 +11,14 @@ import (
 )
 
 func TestUnary(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		let x : int = -100
 		let y : int = !500
 		let z : bool = -false
 		let h : bool = !false
 	`)
 
-	ok, statements := parser.Parse(lex.Tokens)
+	ok, statements := parser.Parse(lex, lex.Tokens)
 	states := []bool{true, false, false, true}
 
 	assert.True(t, ok)
@@ -41,14 +42,14 @@ func TestUnary(t *testing.T) {
 }
 
 func TestBinary(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		let x : int = -100 + 50
 		let y : int = 500 * true
 		let z : bool = false + true 
 		let h : bool = 100 - 5 
 	`)
 
-	ok, statements := parser.Parse(lex.Tokens)
+	ok, statements := parser.Parse(lex, lex.Tokens)
 	states := []bool{true, false, false, true}
 
 	assert.True(t, ok)
@@ -70,3 +71,830 @@ func TestBinary(t *testing.T) {
 		t.Errorf("Expected %d statements, got %d.", 4, len(statements))
 	}
 }
+
+func TestInterpolation(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let x : string = "value: ${100 + 5}"
+		let y : string = "nested ${"inner ${true}"}"
+		let z : string = "function ${fn(): int => 5}"
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		switch s := statement.(type) {
+		case *ast.VariableDeclaration:
+			assert.IsType(t, &ast.Interpolation{}, s.Value)
+
+			_, err := tc.CheckExpression(s.Value)
+
+			assert.Equal(t, states[i], err == nil)
+		default:
+			t.Errorf("Expected statement to be variable declaration.")
+		}
+	}
+}
+
+func TestCheckAssignment(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type User { name: string, age: int }
+		let mut count : int = 0
+		let mut user : User = User { name: "graham", age: 21 }
+		count = count + 1
+		count = "ten"
+		user.age += 1
+		user.name -= "g"
+		missing = 5
+		user.email = "graham@example.com"
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, false, true, false, false, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for _, statement := range statements[:3] {
+		assert.Nil(t, tc.CheckStatement(statement))
+	}
+
+	for i, statement := range statements[3:] {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestImmutableBindings(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type User { name: string, age: int }
+		let count : int = 0
+		let user : User = User { name: "graham", age: 21 }
+		count += 1
+		user.age = 22
+		let increment : (int) -> int = fn (x: int): int => {
+			x = x + 1
+			return x
+		}
+		let decrement : (int) -> int = fn (mut x: int): int => {
+			x = x - 1
+			return x
+		}
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	assert.False(t, tc.CheckAll(statements))
+	assert.Len(t, tc.Errors, 3)
+
+	// the error points back at the declaration of the immutable variable
+	diagnostic := tc.Errors[0].Diagnostic()
+
+	assert.Contains(t, diagnostic.Message, "immutable variable 'count'")
+	assert.Len(t, diagnostic.Labels, 1)
+	assert.Equal(t, 3, diagnostic.Labels[0].Span.Start.Line)
+
+	assert.Contains(t, tc.Errors[1].Error(), "field 'age' of immutable variable 'user'")
+	assert.Contains(t, tc.Errors[2].Error(), "immutable variable 'x'")
+}
+
+func TestMutabilityNotes(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type User { name: string }
+		let count : int = 0
+		count = 1
+		let rename : (User) -> int = fn (user: User): int => {
+			user.name = "bunny"
+			return 0
+		}
+		for (i in 0..10) {
+			i = 5
+		}
+		let mut total : int = 0
+		let sum : int = match total { n => n = 1 }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	assert.False(t, tc.CheckAll(statements))
+	assert.Len(t, tc.Errors, 4)
+
+	// the suggested fix depends on where the variable was declared
+	notes := []string{
+		"declare it with 'let mut count' to allow reassignment",
+		"declare the parameter with 'fn(mut user: ...)' to allow reassignment",
+		"loop variables cannot be reassigned, copy it with 'let mut copy = i' instead",
+		"names bound by a match arm cannot be reassigned, copy it with 'let mut copy = n' instead",
+	}
+
+	for i, note := range notes {
+		assert.Equal(t, []string{note}, tc.Errors[i].Diagnostic().Notes)
+	}
+}
+
+func TestCheckForStatement(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let mut total : int = 0
+		for (i in 0..10) {
+			total += i
+		}
+		for (i in 0..=total) {
+			i = 5
+		}
+		for (i in 0.."ten") {
+		}
+		for (c in "hello") {
+		}
+		for (i in 0..10) {
+			let name : string = i
+		}
+		let s : string = "outer"
+		for (s in [1, 2]) {
+			let n : int = s
+		}
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, false, false, false, false, true, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+
+	// the loop variable is only in scope within the loop
+	_, err := tc.CheckExpression(&ast.VariableExpression{Value: "i"})
+
+	assert.NotNil(t, err)
+
+	// a loop variable can't reuse the name of a variable in scope
+	err = tc.CheckStatement(statements[7])
+
+	assert.Contains(t, err.Error(), "Variable 's' already in scope.")
+}
+
+func TestLoopJumps(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		outer: while (true) {
+			for (i in 0..10) {
+				continue outer
+			}
+			break
+		}
+		break
+		while (true) {
+			break inner
+		}
+		while (true) {
+			let f : () -> int = fn (): int => {
+				break
+				return 0
+			}
+		}
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, false, false, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestArrays(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type User { name: string }
+		let mut xs : [int] = [1, 2, 3]
+		let users : [User] = [User { name: "graham" }]
+		let empty : [string] = []
+		let first : int = xs[0]
+		xs[1] = 5
+		users[0].name = "bunny"
+		let mixed : [int] = [1, "two"]
+		let bad : int = xs["zero"]
+		let nested : string = users[0].name
+		let count : int = first[0]
+		for (x in xs) {
+			let y : int = x
+		}
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, true, true, true, true, false, false, false, true, false, true}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestMaps(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let mut ages : {string: int} = ["graham": 21, "bunny": 3]
+		let empty : {int: bool} = [:]
+		let age : int? = ages["graham"]
+		ages["ralph"] = 7
+		let required : int = ages["graham"]
+		let wrongKey : int? = ages[0]
+		let mixed : {string: int} = ["one": 1, "two": "2"]
+		let badKey : {[int]: string} = [:]
+		let badLiteral : int = [[1]: "one"]
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, true, true, false, false, false, false, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestMapLookups(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Point { x: int, y: int }
+		type Shape = Circle { r: float } | Empty
+		let pairs : {string: (int, int)} = ["a": (1, 2)]
+		let pair : (int, int)? = pairs["a"]
+		let missingPair : (int, int) = pairs["zzz"]
+		let handlers : {string: (int) -> int} = ["inc": fn (x : int) : int => x + 1]
+		let handler : ((int) -> int)? = handlers["inc"]
+		let missingHandler : (int) -> int = handlers["inc"]
+		let points : {string: Point} = ["origin": Point { x: 0, y: 0 }]
+		let point : Point? = points["origin"]
+		let missingPoint : Point = points["origin"]
+		let shapes : {string: Shape} = ["empty": Empty]
+		let shape : Shape? = shapes["empty"]
+		let missingShape : Shape = shapes["empty"]
+		let literal = ["origin": Point { x: 0, y: 0 }]
+		let missingLiteral : Point = literal["origin"]
+		let ages : {string: int} = ["graham": 21]
+		let field : int = ages.graham
+		let first : int = pairs["a"].0
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{
+		true, true, true, true, false, true, true, false, true, true, false,
+		true, true, false, true, false, true, false, false,
+	}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestUnwrapMapLookups(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Shape = Circle { r: float } | Empty
+		let ages : {string: int} = ["graham": 21]
+		let next : int = match ages["graham"] { none => 0, age => age + 1 }
+		let shapes : {string: Shape} = ["empty": Empty]
+		let empty : bool = match shapes["empty"] { none => false, Empty => true, _ => false }
+		let early : int = match ages["graham"] { age => age + 1, none => 0 }
+		let guarded : int = match ages["graham"] { none if false => 0, age => age + 1 }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, true, true, true, false, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestEmptyLiterals(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let xs = []
+		let ints : [int] = xs
+		let strings : [string] = xs
+		let m = [:]
+		let ages : {string: int} = m
+		let flags : {string: bool} = m
+		let mut ys = []
+		ys = [1, 2]
+		ys = ["one"]
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, false, true, true, false, true, true, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestTuples(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let divide : (int, int) -> (int, int) = fn (a: int, b: int): (int, int) => (a / b, a - b)
+		let (quotient, remainder) : (int, int) = divide(10, 3)
+		let mut pair : (int, string) = (1, "one")
+		let first : int = pair.0
+		pair.1 = "uno"
+		let sum : int = quotient + remainder
+		let missing : int = pair.2
+		let (x, y, z) : (int, int) = (1, 2)
+		let (name, count) : (string, int) = (1, 2)
+		let (left, right) : int = 5
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, true, true, true, true, false, false, false, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestRecordDestructuring(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type NumberPair { first: int, second: int }
+		type User { name: string, pair: NumberPair }
+		let pair : NumberPair = NumberPair { first: 1, second: 2 }
+		let user : User = User { name: "graham", pair: pair }
+		let { first, second } : NumberPair = pair
+		let { name: n, pair: { first: a } } = user
+		let sum : int = first + second + a
+		let greeting : string = n
+		let { age } = user
+		let { first: duplicate, second: duplicate } = pair
+		let { x } = 5
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, true, true, true, true, true, true, false, false, false}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestSumTypes(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Shape = Circle { r: float } | Square { side: float } | Empty
+		type List = Cons { head: int, tail: List } | Nil
+		let circle : Shape = Circle { r: 1.5 }
+		let empty : Shape = Empty
+		let list : List = Cons { head: 1, tail: Cons { head: 2, tail: Nil } }
+		let area : (Shape) -> int = fn (shape: Shape): int => 0
+		let zero : int = area(Square { side: 2.0 })
+		let wrong : Shape = Nil
+		let missing : Shape = Circle { }
+		let extra : Shape = Empty { r: 1.0 }
+		let mistyped : Shape = Circle { r: "one" }
+		let bare : Shape = Square
+		let radius : float = circle.r
+		type Shape = Triangle
+		type Other = Empty
+		type Duplicate = One | One
+		type Unknown = Wrapper { value: Missing }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{
+		true, true, true, true, true, true, true,
+		false, false, false, false, false, false, false, false, false, false,
+	}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestBuiltinTypeNames(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type int { x: int, y: int }
+		type string = Empty | Full { value: int }
+		type array { length: int }
+		let y : int = 5
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{false, false, false, true}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestSumTypeEquality(t *testing.T) {
+	shape := &ast.SumType{Name: "Shape"}
+
+	assert.True(t, shape.Equals(&ast.SumType{Name: "Shape"}))
+	assert.False(t, shape.Equals(&ast.SumType{Name: "List"}))
+	assert.True(t, shape.Equals(ast.CreateVariableType("Shape", false)))
+	assert.True(t, ast.CreateVariableType("Shape", false).Equals(shape))
+	assert.False(t, ast.CreateVariableType("Shape", true).Equals(shape))
+	assert.False(t, shape.Equals(ast.CreateVariableType("int", false)))
+	assert.False(t, shape.Equals(ast.CreateTupleType(nil)))
+}
+
+func TestCheckMatchExpression(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Shape = Circle { r: float } | Square { side: float } | Empty
+		let shape : Shape = Circle { r: 2.0 }
+		let area : float = match shape {
+			Circle { r } if r > 1.0 => r * r * 3.14,
+			Square { side } => side * side,
+			_ => 0.0,
+		}
+		let label : string = match (1, [true, false]) {
+			(0, [first, ..rest]) => "zero",
+			(n, []) if n > 0 => "positive",
+			(_, [..]) => "other",
+		}
+		let sign : int = match -5 { -5 => 1, 0 => 0, x => x }
+		let text : int = match 1 { "one" => 0, n => n }
+		let mixed : int = match shape { Empty => 0, _ => "one" }
+		let unknown : int = match shape { Triangle {} => 0, _ => 1 }
+		let wrongLiteral : int = match shape { 5 => 0, _ => 1 }
+		let guard : int = match 5 { n if n => 0, _ => 1 }
+		let scoped : float = match shape { Circle { r } => r, _ => r }
+		let missingField : float = match shape { Square { r } => r, _ => 0.0 }
+		let (a, 1) = (1, 2)
+		let { r } = shape
+		let constant : int = match 5 { Empty => 1, _ => 0 }
+		type Color = Red | Green
+		let foreign : int = match shape { Red => 1, _ => 0 }
+		let inferred = fn (s) => match s { Empty => 1, _ => 0 }
+		let count : int = inferred(shape)
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{
+		true, true, true, true, true, false, false, false, false, false, false, false, false, false,
+		false, true, false, true, true,
+	}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestExhaustiveness(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Shape = Circle { r: float } | Square { side: float } | Empty
+		let shape : Shape = Circle { r: 2.0 }
+		let sides : int = match shape { Circle {} => 0, Square {} => 4, Empty => 0 }
+		let both : int = match (true, false) { (true, _) => 1, (_, true) => 2, (false, false) => 3 }
+		let length : int = match [1, 2] { [] => 0, [x] => 1, [x, y, ..rest] => 2 }
+		let circle : int = match shape { Circle { r } => 1 }
+		let pair : int = match (true, false) { (true, _) => 1, (_, true) => 2 }
+		let short : int = match [1, 2] { [] => 0, [x] => 1 }
+		let guarded : int = match shape { Circle { r } if r > 1.0 => 1, Square {} => 2, Empty => 3 }
+		let number : int = match 5 { 1 => 0, 2 => 1 }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+	missing := [][]string{
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		{"missing case: Square { .. }", "missing case: Empty"},
+		{"missing case: (false, false)"},
+		{"missing case: [_, _, ..]"},
+		{"missing case: Circle { .. }"},
+		{"missing case: _"},
+	}
+
+	for i, statement := range statements {
+		err := tc.CheckStatement(statement)
+
+		if missing[i] == nil {
+			assert.Nil(t, err, statement.String())
+			continue
+		}
+
+		assert.Equal(t, missing[i], err.(*typechecker.TypeError).Diagnostic().Notes, statement.String())
+	}
+
+	assert.Empty(t, tc.Warnings)
+}
+
+func TestUnreachableArms(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Shape = Circle { r: float } | Square { side: float } | Empty
+		let shape : Shape = Empty
+		let all : int = match shape { Circle {} => 1, Square {} => 2, Empty => 3, _ => 4 }
+		let prefix : int = match [1, 2] { [] => 0, [x, ..rest] => 1, [x] => 2 }
+		let repeated : int = match 5 { 1 => 0, 1 => 1, _ => 2 }
+		let guarded : int = match 5 { n if n > 1 => 0, 1 => 1, _ => 2 }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	assert.True(t, tc.CheckAll(statements))
+	assert.Len(t, tc.Warnings, 3)
+
+	lines := make([]int, len(tc.Warnings))
+
+	for i, warning := range tc.Warnings {
+		assert.Equal(t, diagnostics.Warning, warning.Diagnostic().Severity)
+		lines[i] = warning.GetSpan().Start.Line
+	}
+
+	assert.Equal(t, []int{4, 5, 6}, lines)
+}
+
+func TestOptionalPatterns(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let either = fn (o : int?) : int => match o { none => 0, v => 1 }
+		let literals = fn (o : bool?) : int => match o { none => 0, true => 1, false => 2 }
+		let missingSome = fn (o : int?) : int => match o { none => 0 }
+		let missingNone = fn (o : bool?) : int => match o { true => 1, false => 2 }
+		let required = fn (n : int) : int => match n { none => 0, _ => 1 }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+	missing := [][]string{
+		nil,
+		nil,
+		{"missing case: _"},
+		{"missing case: none"},
+	}
+
+	for i, statement := range statements[:len(missing)] {
+		err := tc.CheckStatement(statement)
+
+		if missing[i] == nil {
+			assert.Nil(t, err, statement.String())
+			continue
+		}
+
+		assert.Equal(t, missing[i], err.(*typechecker.TypeError).Diagnostic().Notes, statement.String())
+	}
+
+	assert.NotNil(t, tc.CheckStatement(statements[len(missing)]))
+	assert.Empty(t, tc.Warnings)
+}
+
+func TestNone(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Point { x: int, y: int }
+		let o : int? = none
+		let p : Point? = none
+		let pair : (int, string)? = none
+		let find = fn (id : int) : string? => {
+			if (id == 0) {
+				return none
+			}
+
+			return none
+		}
+		let orZero = fn (value : int?) : int => match value { none => 0, v => v }
+		let zero : int = orZero(none)
+		let missing : bool = o == none
+		let required : int = none
+		let inferred = none
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{true, true, true, true, true, true, true, true, false, true}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestUnreachableOptionalArms(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let either = fn (o : int?) : int => match o { none => 0, v => 1 }
+		let binding = fn (o : int?) : int => match o { v => 1, none => 0 }
+		let repeated = fn (o : int?) : int => match o { none => 0, none => 1, _ => 2 }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	assert.True(t, tc.CheckAll(statements))
+	assert.Len(t, tc.Warnings, 2)
+
+	lines := make([]int, len(tc.Warnings))
+
+	for i, warning := range tc.Warnings {
+		lines[i] = warning.GetSpan().Start.Line
+	}
+
+	assert.Equal(t, []int{3, 4}, lines)
+}
+
+func TestCheckGenerics(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Box<T> { value: T }
+		type Pair<A, B> { first: A, second: B }
+		let identity : <T>(T) -> T = fn<T>(x: T): T => x
+		let same : <U>(U) -> U = identity
+		let five : int = identity(5)
+		let name : string = identity("glam")
+		let box : Box<int> = Box { value: 1 }
+		let inner : int = box.value
+		let unwrap : <T>(Box<T>) -> T = fn<T>(b: Box<T>): T => b.value
+		let one : int = unwrap(box)
+		let two : int = unwrap(Box { value: 2 })
+		let swap : <A, B>(Pair<A, B>) -> Pair<B, A> = fn<A, B>(p: Pair<A, B>): Pair<B, A> => Pair {
+			first: p.second,
+			second: p.first
+		}
+		let swapped : Pair<string, int> = swap(Pair { first: 1, second: "one" })
+		let head : <T>([T]) -> T = fn<T>(xs: [T]): T => xs[0]
+		let f : float = head([1.0, 2.0])
+		let choose : <T>(T, T) -> T = fn<T>(a: T, b: T): T => a
+		let wrong : string = identity(5)
+		let missing : Box = Box { value: 1 }
+		let tooMany : Box<int, int> = Box { value: 1 }
+		let primitive : int<string> = 5
+		let mismatch : Box<int> = Box { value: "one" }
+		let both : int = choose(1, "two")
+		let opaque : <T>(T) -> int = fn<T>(x: T): int => x + 1
+		let empty : <T>() -> [T] = fn<T>(): [T] => []
+		let unknown : [int] = empty()
+		type Duplicate<T, T> { value: T }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{
+		true, true, true, true, true, true, true, true, true, true, true, true, true, true, true, true,
+		false, false, false, false, false, false, false, true, true, false,
+	}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	for i, statement := range statements {
+		assert.Equal(t, states[i], tc.CheckStatement(statement) == nil, statement.String())
+	}
+}
+
+func TestTypeInference(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let x = 100
+		let add = fn(a, b) => a + b
+		let sum : int = add(x, 1)
+		let identity = fn(v) => v
+		let five : int = identity(5)
+		let name : string = identity("glam")
+		let increment : (int) -> int = identity
+		let apply = fn(f, v) => f(v)
+		let shout : string = apply(fn(s) => s + "!", "hi")
+		let factorial = fn(n) => {
+			if (n == 0) {
+				return 1
+			}
+
+			return n * factorial(n - 1)
+		}
+		let first = fn(pair) => match pair { (a, b) => a }
+		let one : int = first((1, "one"))
+		let wrong = add(true, false)
+		let field = fn(user) => user.name
+		let loop = fn(f) => f(f)
+		let mismatch : string = identity(5)
+		let invert = fn(b) => !b
+		let negate = fn(n) => -n
+		let half : float = negate(add(1.5, 2.5))
+		let joined : string = add("a", "b")
+		let mixed = add(1, 2.5)
+		let text = negate("a")
+		let outer = fn(v) => {
+			let inner = fn(w) => v
+			return inner
+		}
+		let constant = outer(1)
+		let captured : string = constant("a")
+		let kept : int = constant("a")
+		let broken = fn(a) => a + true
+		let reused : float = broken(1.5)
+		let unknown : int = []
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+	states := []bool{
+		true, true, true, true, true, true, true, true, true, true, true, true,
+		false, false, false, false,
+		true, true, true, true, false, false,
+		true, true, false, true,
+		false, false, false,
+	}
+
+	assert.True(t, ok)
+
+	tc := typechecker.CreateTypeChecker()
+
+	errs := make([]error, len(statements))
+
+	for i, statement := range statements {
+		errs[i] = tc.CheckStatement(statement)
+
+		assert.Equal(t, states[i], errs[i] == nil, statement.String())
+	}
+
+	// records are structural, so a field access alone can't tell which record a parameter is
+	assert.Contains(t, errs[13].Error(), "Cannot infer the type of this value.")
+
+	// a variable whose declaration failed isn't reported again when it is used
+	_, isTypeError := errs[27].(*typechecker.TypeError)
+	assert.False(t, isTypeError)
+
+	// types which haven't been inferred yet are shown as _
+	assert.Contains(t, errs[28].Error(), "Expected int but got [_].")
+
+	// functions are generalized, while the types of other values are fixed
+	types := map[string]string{
+		"x":         "int",
+		"add":       "<T: float | int | string>(T, T) -> T",
+		"identity":  "<T>(T) -> T",
+		"apply":     "<T, U>((T) -> U, T) -> U",
+		"factorial": "(int) -> int",
+		"first":     "<T, U>((T, U)) -> T",
+		"invert":    "(bool) -> bool",
+		"negate":    "<T: float | int>(T) -> T",
+		"outer":     "<U>(U) -> <T>(T) -> U",
+		"constant":  "<T>(T) -> int",
+	}
+
+	for name, expected := range types {
+		variableType, err := tc.CheckExpression(&ast.VariableExpression{Value: name})
+
+		assert.Nil(t, err)
+		assert.Equal(t, expected, variableType.String(), name)
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/lexer"
+	"github.com/gmisail/glamlang/parser"
+	"github.com/gmisail/glamlang/typechecker"
+)
+
+func TestRenderDiagnostic(t *testing.T) {
+	color.NoColor = true
+
+	lex := lexer.ScanText("main.gl", "let x : int = 5\nlet name : string = x + 1\n")
+	_, statements := parser.Parse(lex, lex.Tokens)
+
+	tc := typechecker.CreateTypeChecker()
+
+	assert.False(t, tc.CheckAll(statements))
+	assert.Len(t, tc.Errors, 1)
+
+	var output bytes.Buffer
+
+	diagnostics.Render(&output, lex.Input, tc.Errors[0].Diagnostic().WithNote("convert the value to a string"))
+
+	expected := `error[T0001]: Invalid type in variable declaration. Expected string but got int.
+ --> main.gl:2:21
+  |
+2 | let name : string = x + 1
+  |                     ^^^^^
+  |            ------ expected due to this type
+  |
+  = help: convert the value to a string
+`
+
+	assert.Equal(t, expected, output.String())
+}
+
+func TestRenderLexerDiagnostic(t *testing.T) {
+	color.NoColor = true
+
+	lex := lexer.ScanText("main.gl", "let x : int =\n\t10px")
+
+	assert.Len(t, lex.Errors, 1)
+
+	var output bytes.Buffer
+
+	diagnostics.Render(&output, lex.Input, lex.Errors[0].Diagnostic())
+
+	expected := "error[L0002]: Invalid character 'p' in number literal.\n" +
+		" --> main.gl:2:2\n" +
+		"  |\n" +
+		"2 | \t10px\n" +
+		"  | \t^^^^\n"
+
+	assert.Equal(t, expected, output.String())
+}
+
+func TestRenderJSONDiagnostic(t *testing.T) {
+	lex := lexer.ScanText("main.gl", "let x : int = 5\nlet name : string = x + 1\n")
+	_, statements := parser.Parse(lex, lex.Tokens)
+
+	tc := typechecker.CreateTypeChecker()
+
+	assert.False(t, tc.CheckAll(statements))
+
+	report := []*diagnostics.Diagnostic{tc.Errors[0].Diagnostic()}
+
+	assert.True(t, diagnostics.HasErrors(report))
+
+	var output bytes.Buffer
+
+	assert.Nil(t, diagnostics.RenderJSON(&output, lex.Input, report))
+
+	var decoded struct {
+		Diagnostics []struct {
+			Severity string
+			Code     string
+			Message  string
+			Location struct {
+				File        string
+				StartLine   int
+				StartColumn int
+				EndLine     int
+				EndColumn   int
+			}
+			Related []struct {
+				StartLine   int
+				StartColumn int
+				Message     string
+			}
+		}
+	}
+
+	assert.Nil(t, json.Unmarshal(output.Bytes(), &decoded))
+	assert.Len(t, decoded.Diagnostics, 1)
+
+	d := decoded.Diagnostics[0]
+
+	assert.Equal(t, "error", d.Severity)
+	assert.Equal(t, diagnostics.MismatchedTypesCode, d.Code)
+	assert.Equal(t, "main.gl", d.Location.File)
+	assert.Equal(t, 2, d.Location.StartLine)
+	assert.Equal(t, 21, d.Location.StartColumn)
+	assert.Equal(t, 2, d.Location.EndLine)
+	assert.Equal(t, 26, d.Location.EndColumn)
+	assert.Len(t, d.Related, 1)
+	assert.Equal(t, 12, d.Related[0].StartColumn)
+	assert.Equal(t, "expected due to this type", d.Related[0].Message)
+}
+
+func TestDiagnosticCodes(t *testing.T) {
+	lex := lexer.ScanText("main.gl", "let x = \"abc\nlet y = 10px")
+
+	assert.Len(t, lex.Errors, 2)
+	assert.Equal(t, diagnostics.UnterminatedCode, lex.Errors[0].Diagnostic().Code)
+	assert.Equal(t, diagnostics.InvalidNumberCode, lex.Errors[1].Diagnostic().Code)
+
+	lex = lexer.ScanText("main.gl", "let x : int = \nlet y = (1 + ")
+	_, parseErrors := parser.ParseProgram(lex, lex.Tokens)
+
+	assert.Len(t, parseErrors, 2)
+	assert.Equal(t, diagnostics.UnexpectedTokenCode, parseErrors[0].Diagnostic().Code)
+	assert.Equal(t, diagnostics.UnexpectedEndCode, parseErrors[1].Diagnostic().Code)
+
+	tests := map[string]string{
+		"let x : string = 5":      diagnostics.MismatchedTypesCode,
+		"let x = y":               diagnostics.UndefinedNameCode,
+		"let x = 5\nlet x = 6":    diagnostics.DuplicateNameCode,
+		"let x = 5\nx = 6":        diagnostics.InvalidAssignmentCode,
+		"let x = 5\nlet y = x(1)": diagnostics.InvalidCallCode,
+		"break":                   diagnostics.InvalidJumpCode,
+		"let f = fn(n: int): int => {\n\tn + 1\n}": diagnostics.MissingReturnCode,
+		"type int { x: int }":                      diagnostics.InvalidDeclarationCode,
+	}
+
+	for input, code := range tests {
+		lex := lexer.ScanText("main.gl", input)
+		_, statements := parser.Parse(lex, lex.Tokens)
+
+		tc := typechecker.CreateTypeChecker()
+
+		assert.False(t, tc.CheckAll(statements), input)
+
+		if assert.Len(t, tc.Errors, 1, input) {
+			assert.Equal(t, code, tc.Errors[0].Diagnostic().Code, input)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/gmisail/glamlang/lexer"
+	"github.com/gmisail/glamlang/parser"
+	"github.com/gmisail/glamlang/typechecker"
+)
+
+var fuzzSeeds = []string{
+	"let x : int = 100",
+	"let add : (int, int) -> int = fn (a : int, b : int) : int => a + b",
+	"let inc = fn (a) => a + 1",
+	"type Point { x: int, y: int }",
+	"let p : Point = Point { x: 1, y: 2 }",
+	"while (true) { if (x == 1) { return x } else { return 2 } }",
+	"for (i in 0..10) { let y : int = i }",
+	`let s : string = "hello ${name}!"`,
+	"type Shape = Circle { r: float } | Empty\nlet area = fn (s: Shape) => match s { Circle { r } => r, Empty => 0.0 }",
+	"sum(",
+	"{",
+	"let x : ",
+	"type Point {",
+	"fn (a : int) : int =>",
+	"Point { x: ",
+	"while (true)",
+	"if (true)",
+	"if (true) {} else",
+	"for (i in xs)",
+	"let f = fn () =>",
+	"type int { x: int, y: int }\nlet y : int = 5",
+}
+
+/*
+Parses the input and type checks whatever could be parsed, which should only
+ever produce errors rather than panicking.
+*/
+func checkInput(input string) {
+	lex := lexer.ScanText("fuzz", input)
+	_, statements := parser.Parse(lex, lex.Tokens)
+
+	typechecker.CreateTypeChecker().CheckAll(statements)
+}
+
+/*
+Truncated or malformed input should only ever produce parse errors.
+*/
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		checkInput(input)
+	})
+}
+
+/*
+Every prefix of a valid program is a truncated program.
+*/
+func FuzzParseTruncated(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, uint(len(seed)/2))
+	}
+
+	f.Fuzz(func(t *testing.T, input string, length uint) {
+		if int(length) > len(input) {
+			length = uint(len(input))
+		}
+
+		checkInput(input[:length])
+	})
+}
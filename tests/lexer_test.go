@@ -1,25 +1,28 @@
 package tests
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/gmisail/glamlang/lexer"
 )
 
 func TestNumberOfTokens(t *testing.T) {
-	lex := lexer.ScanTokens("[](){}.,    +-*/")
+	lex := lexer.ScanText("test", "[](){}.,    +-*/")
 
 	numTokens := len(lex.Tokens)
 
-	if numTokens != 12 {
-		t.Errorf("Found %d tokens, expected 12.", numTokens)
+	if numTokens != 13 {
+		t.Errorf("Found %d tokens, expected 13.", numTokens)
 	}
 }
 
 func TestKeywords(t *testing.T) {
-	lex := lexer.ScanTokens("hello let while for if else true false")
+	lex := lexer.ScanText("test", "hello let while for if else true false none")
 	expected := []lexer.TokenType{
-		lexer.IDENTIFIER, lexer.LET, lexer.WHILE, lexer.FOR, lexer.IF, lexer.ELSE, lexer.TRUE, lexer.FALSE,
+		lexer.IDENTIFIER, lexer.LET, lexer.WHILE, lexer.FOR, lexer.IF, lexer.ELSE, lexer.TRUE, lexer.FALSE, lexer.NULL,
+		lexer.END_OF_FILE,
 	}
 
 	numTokens := len(lex.Tokens)
@@ -36,15 +39,15 @@ func TestKeywords(t *testing.T) {
 }
 
 func TestNumbers(t *testing.T) {
-	lex := lexer.ScanTokens("100 123456 12.14 5000.00")
+	lex := lexer.ScanText("test", "100 123456 12.14 5000.00")
 	expected := []lexer.TokenType{
-		lexer.INT, lexer.INT, lexer.FLOAT, lexer.FLOAT,
+		lexer.INT, lexer.INT, lexer.FLOAT, lexer.FLOAT, lexer.END_OF_FILE,
 	}
 
 	numTokens := len(lex.Tokens)
 
-	if numTokens != 4 {
-		t.Errorf("Found %d tokens, expected 4.", numTokens)
+	if numTokens != 5 {
+		t.Errorf("Found %d tokens, expected 5.", numTokens)
 	}
 
 	for i, tok := range lex.Tokens {
@@ -55,15 +58,15 @@ func TestNumbers(t *testing.T) {
 }
 
 func TestString(t *testing.T) {
-	lex := lexer.ScanTokens("hello \"from way up here\"")
+	lex := lexer.ScanText("test", "hello \"from way up here\"")
 	expected := []lexer.TokenType{
-		lexer.IDENTIFIER, lexer.STRING,
+		lexer.IDENTIFIER, lexer.STRING, lexer.END_OF_FILE,
 	}
 
 	numTokens := len(lex.Tokens)
 
-	if numTokens != 2 {
-		t.Errorf("Found %d tokens, expected 2.", numTokens)
+	if numTokens != 3 {
+		t.Errorf("Found %d tokens, expected 3.", numTokens)
 	}
 
 	for i, tok := range lex.Tokens {
@@ -74,15 +77,255 @@ func TestString(t *testing.T) {
 }
 
 func TestConditionalTokens(t *testing.T) {
-	lex := lexer.ScanTokens("=> == != ->")
+	lex := lexer.ScanText("test", "=> == != ->")
 	expected := []lexer.TokenType{
-		lexer.THICK_ARROW, lexer.EQUALITY, lexer.NOT_EQUAL, lexer.ARROW,
+		lexer.THICK_ARROW, lexer.EQUALITY, lexer.NOT_EQUAL, lexer.ARROW, lexer.END_OF_FILE,
 	}
 
 	numTokens := len(lex.Tokens)
 
-	if numTokens != 4 {
-		t.Errorf("Found %d tokens, expected 4.", numTokens)
+	if numTokens != 5 {
+		t.Errorf("Found %d tokens, expected 5.", numTokens)
+	}
+
+	for i, tok := range lex.Tokens {
+		if tok.Type != expected[i] {
+			t.Errorf("Token '%s' has type %d, expecting %d.", tok.Literal, tok.Type, expected[i])
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	lex := lexer.ScanText("test", `let # trailing comment
+		#{ block #{ nested }# comment }# x
+		#{
+			multiline
+		}# y`)
+	expected := []lexer.TokenType{
+		lexer.LET, lexer.IDENTIFIER, lexer.IDENTIFIER, lexer.END_OF_FILE,
+	}
+
+	numTokens := len(lex.Tokens)
+
+	if numTokens != len(expected) {
+		t.Errorf("Found %d tokens, expected %d.", numTokens, len(expected))
+	}
+
+	for i, tok := range lex.Tokens {
+		if tok.Type != expected[i] {
+			t.Errorf("Token '%s' has type %d, expecting %d.", tok.Literal, tok.Type, expected[i])
+		}
+	}
+
+	if lex.Tokens[1].Line != 2 || lex.Tokens[2].Line != 5 {
+		t.Errorf("Expected tokens on lines 2 and 5, got %d and %d.", lex.Tokens[1].Line, lex.Tokens[2].Line)
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	lex := lexer.ScanText("test", `"tab\there\n" "\"quoted\" \\ \u{1F600}" """raw \n
+string"""`)
+	expected := []string{"tab\there\n", "\"quoted\" \\ \U0001F600", "raw \\n\nstring"}
+
+	numTokens := len(lex.Tokens)
+
+	// every string followed by END_OF_FILE
+	if numTokens != len(expected)+1 {
+		t.Fatalf("Found %d tokens, expected %d.", numTokens, len(expected)+1)
+	}
+
+	for i, tok := range lex.Tokens[:len(expected)] {
+		if tok.Type != lexer.STRING {
+			t.Errorf("Token '%s' has type %d, expecting %d.", tok.Literal, tok.Type, lexer.STRING)
+		}
+
+		if tok.Value != expected[i] {
+			t.Errorf("Expected string value %q, got %q.", expected[i], tok.Value)
+		}
+	}
+
+	if lex.Tokens[0].Literal != `tab\there\n` {
+		t.Errorf("Expected literal to keep escape sequences, got %q.", lex.Tokens[0].Literal)
+	}
+}
+
+func TestInterpolationTokens(t *testing.T) {
+	lex := lexer.ScanText("test", `"hello ${user.name}, you are ${age}" "\${literal}"`)
+	expected := []lexer.TokenType{
+		lexer.INTERPOLATION_START, lexer.IDENTIFIER, lexer.PERIOD, lexer.IDENTIFIER,
+		lexer.INTERPOLATION_MIDDLE, lexer.IDENTIFIER, lexer.INTERPOLATION_END, lexer.STRING, lexer.END_OF_FILE,
+	}
+
+	numTokens := len(lex.Tokens)
+
+	if numTokens != len(expected) {
+		t.Errorf("Found %d tokens, expected %d.", numTokens, len(expected))
+	}
+
+	for i, tok := range lex.Tokens {
+		if tok.Type != expected[i] {
+			t.Errorf("Token '%s' has type %d, expecting %d.", tok.Literal, tok.Type, expected[i])
+		}
+	}
+
+	if lex.Tokens[7].Value != "${literal}" {
+		t.Errorf("Expected escaped interpolation to be a plain string, got %q.", lex.Tokens[7].Value)
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	lex := lexer.ScanText("test", "0xFF 0b1010 0o17 1_000_000 6.02e23 1.5e-3 2E3")
+	expected := []interface{}{
+		int64(255), int64(10), int64(15), int64(1000000), 6.02e23, 1.5e-3, 2e3,
+	}
+
+	numTokens := len(lex.Tokens)
+
+	if numTokens != len(expected)+1 {
+		t.Fatalf("Found %d tokens, expected %d.", numTokens, len(expected)+1)
+	}
+
+	for i, tok := range lex.Tokens[:len(expected)] {
+		if tok.Value != expected[i] {
+			t.Errorf("Token '%s' has value %v, expecting %v.", tok.Literal, tok.Value, expected[i])
+		}
+	}
+}
+
+type failingReader struct{}
+
+func (f failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestScanReader(t *testing.T) {
+	lex, err := lexer.ScanReader("reader", strings.NewReader("let x : int = 5"))
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if len(lex.Tokens) != 7 {
+		t.Errorf("Found %d tokens, expected 7.", len(lex.Tokens))
+	}
+
+	if lex.Input.Name != "reader" {
+		t.Errorf("Expected source name \"reader\", got \"%s\".", lex.Input.Name)
+	}
+
+	if _, err := lexer.ScanReader("broken", failingReader{}); err == nil {
+		t.Errorf("Expected an error when the reader fails.")
+	}
+
+	if _, err := lexer.ScanFile("does/not/exist.gl"); err == nil {
+		t.Errorf("Expected an error when the file does not exist.")
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	lex := lexer.ScanText("test", `let a : int = 10px
+		let b : string = "bad \q escape"
+		@ 0x 1__0
+		let c : int = 5`)
+
+	if len(lex.Errors) != 5 {
+		t.Errorf("Found %d errors, expected 5.", len(lex.Errors))
+	}
+
+	expectedLines := []int{1, 2, 3, 3, 3}
+
+	for i, err := range lex.Errors {
+		if i < len(expectedLines) && err.GetLine() != expectedLines[i] {
+			t.Errorf("Error '%s' reported on line %d, expected %d.", err.Error(), err.GetLine(), expectedLines[i])
+		}
+	}
+
+	errorTokens := 0
+
+	for _, tok := range lex.Tokens {
+		if tok.Type == lexer.ERROR {
+			errorTokens += 1
+		}
+	}
+
+	if errorTokens != 4 {
+		t.Errorf("Found %d error tokens, expected 4.", errorTokens)
+	}
+
+	// lexing continues after the errors
+	last := lex.Tokens[len(lex.Tokens)-2]
+
+	if last.Type != lexer.INT || last.Line != 4 {
+		t.Errorf("Expected the final token to be an INT on line 4, got %s on line %d.", lexer.TokenTypeToString(last.Type), last.Line)
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	lex := lexer.ScanText("test", `let größe : string = "héllo 😀" 名前`)
+
+	if len(lex.Errors) != 0 {
+		t.Errorf("Expected no errors, got %d.", len(lex.Errors))
+	}
+
+	identifier := lex.Tokens[1]
+
+	if identifier.Type != lexer.IDENTIFIER || identifier.Literal != "größe" {
+		t.Errorf("Expected identifier \"größe\", got %q.", identifier.Literal)
+	}
+
+	str := lex.Tokens[5]
+
+	if str.Value != "héllo 😀" {
+		t.Errorf("Expected string value \"héllo 😀\", got %q.", str.Value)
+	}
+
+	// columns count characters while offsets and lengths count bytes
+	last := lex.Tokens[6]
+
+	if last.Relative != 31 || last.Absolute != 37 || last.Length != 6 {
+		t.Errorf(
+			"Expected column 31, offset 37 and length 6, got %d, %d and %d.",
+			last.Relative, last.Absolute, last.Length,
+		)
+	}
+
+	line, column := lex.Input.Position(last.Absolute)
+
+	if line != 1 || column != 31 {
+		t.Errorf("Expected position 1:31, got %d:%d.", line, column)
+	}
+}
+
+func TestRangeTokens(t *testing.T) {
+	lex := lexer.ScanText("test", "0..10 0..=10 1.5 a.b")
+	expected := []lexer.TokenType{
+		lexer.INT, lexer.RANGE, lexer.INT, lexer.INT, lexer.RANGE_INCLUSIVE, lexer.INT,
+		lexer.FLOAT, lexer.IDENTIFIER, lexer.PERIOD, lexer.IDENTIFIER, lexer.END_OF_FILE,
+	}
+
+	numTokens := len(lex.Tokens)
+
+	if numTokens != len(expected) {
+		t.Fatalf("Found %d tokens, expected %d.", numTokens, len(expected))
+	}
+
+	for i, tok := range lex.Tokens {
+		if tok.Type != expected[i] {
+			t.Errorf("Token '%s' has type %d, expecting %d.", tok.Literal, tok.Type, expected[i])
+		}
+	}
+}
+
+func TestTupleFieldTokens(t *testing.T) {
+	lex := lexer.ScanText("test", "pair.0.1 2.5")
+	expected := []lexer.TokenType{
+		lexer.IDENTIFIER, lexer.PERIOD, lexer.INT, lexer.PERIOD, lexer.INT, lexer.FLOAT, lexer.END_OF_FILE,
+	}
+
+	numTokens := len(lex.Tokens)
+
+	if numTokens != len(expected) {
+		t.Fatalf("Found %d tokens, expected %d.", numTokens, len(expected))
 	}
 
 	for i, tok := range lex.Tokens {
@@ -9,14 +9,14 @@ import (
 )
 
 func TestLogical(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		((100 - 100) == 0) and false
 		i == 0 or i != 100
 		true and ((100 - 100) == 0)
 		true or false
 	`)
 
-	ok, statements := parser.Parse(lex.Tokens)
+	ok, statements := parser.Parse(lex, lex.Tokens)
 
 	assert.True(t, ok)
 
@@ -26,14 +26,14 @@ func TestLogical(t *testing.T) {
 }
 
 func TestEquality(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		((100 - 100) == 0) == false
 		i == (i != 100)
 		true != ((100 - 100) == 0)
 		true != false
 	`)
 
-	ok, statements := parser.Parse(lex.Tokens)
+	ok, statements := parser.Parse(lex, lex.Tokens)
 
 	assert.True(t, ok)
 
@@ -43,14 +43,14 @@ func TestEquality(t *testing.T) {
 }
 
 func TestComparison(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		((100 - 100) == 0) > false
 		i < (i != 100)
 		true <= ((100 - 100) == 0)
 		true >= false
 	`)
 
-	ok, statements := parser.Parse(lex.Tokens)
+	ok, statements := parser.Parse(lex, lex.Tokens)
 
 	assert.True(t, ok)
 
@@ -58,3 +58,29 @@ func TestComparison(t *testing.T) {
 		t.Errorf("Expected %d statements, got %d.", 4, len(statements))
 	}
 }
+
+func TestAssignmentPrecedence(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		x = y = 10
+		user.age += 1
+		total *= 2 + 3
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+	assert.Len(t, statements, 3)
+
+	// assignment is right associative
+	assert.Equal(
+		t,
+		"(ExpressionStatement body: (Assignment EQUAL (VariableExpression x) (Assignment EQUAL (VariableExpression y) (Literal 10))))",
+		statements[0].String(),
+	)
+
+	lex = lexer.ScanText("test", "1 + x = 5")
+	_, errors := parser.ParseProgram(lex, lex.Tokens)
+
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Error(), "Invalid assignment target.")
+}
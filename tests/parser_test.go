@@ -8,17 +8,18 @@ import (
 	"github.com/gmisail/glamlang/ast"
 	"github.com/gmisail/glamlang/lexer"
 	"github.com/gmisail/glamlang/parser"
+	"github.com/gmisail/glamlang/typechecker"
 )
 
 func TestVariableDeclarations(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		let x : int = 100
 		let y : string? = "hello"
 		let z : float?
 		let h : bool
 	`)
 
-	_, statements := parser.Parse(lex.Tokens)
+	_, statements := parser.Parse(lex, lex.Tokens)
 
 	if len(statements) != 4 {
 		t.Errorf("Expected %d statements, got %d.", 4, len(statements))
@@ -36,8 +37,8 @@ func TestVariableDeclarations(t *testing.T) {
 		t.Errorf("Expected \"%s\", got \"%s\".", "int", xType.Base)
 	}
 
-	if xValue.Value != "100" {
-		t.Errorf("Expected \"%s\", got \"%s\".", "100", xValue.Value)
+	if xValue.Value != int64(100) {
+		t.Errorf("Expected %d, got %v.", 100, xValue.Value)
 	}
 
 	y := statements[1].(*ast.VariableDeclaration)
@@ -54,11 +55,11 @@ func TestVariableDeclarations(t *testing.T) {
 }
 
 func TestFunctionType(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		let square : (int, int) -> int
 	`)
 
-	_, statements := parser.Parse(lex.Tokens)
+	_, statements := parser.Parse(lex, lex.Tokens)
 
 	if len(statements) != 1 {
 		t.Errorf("Expected %d statements, got %d.", 1, len(statements))
@@ -72,15 +73,154 @@ func TestFunctionType(t *testing.T) {
 	}
 }
 
+func TestArrayType(t *testing.T) {
+	lex := lexer.ScanText("test", "let grid : [[int]]? = [[1, 2], [3, 4],]")
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	declaration := statements[0].(*ast.VariableDeclaration)
+	arrayType := declaration.Type.(*ast.VariableType)
+
+	assert.Equal(t, "[[int]]?", arrayType.String())
+	assert.True(t, arrayType.IsArray())
+	assert.Equal(t, "[int]", arrayType.SubType.String())
+	assert.Len(t, declaration.Value.(*ast.ArrayLiteral).Values, 2)
+}
+
+func TestMapLiteral(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let ages : {string: int} = ["graham": 21, "bunny": 3,]
+		let empty : {string: [int]}? = [:]
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	ages := statements[0].(*ast.VariableDeclaration)
+
+	assert.Equal(t, "{string: int}", ages.Type.String())
+	assert.Len(t, ages.Value.(*ast.MapLiteral).Entries, 2)
+
+	empty := statements[1].(*ast.VariableDeclaration)
+
+	assert.Equal(t, "{string: [int]}?", empty.Type.String())
+	assert.Len(t, empty.Value.(*ast.MapLiteral).Entries, 0)
+}
+
+func TestTupleType(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let pair : (int, string) = (1, "one")
+		let f : (int, string) -> (int, int) = g
+		let (a, (b, c)) : (int, (int, int)) = (1, (2, 3))
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	pair := statements[0].(*ast.VariableDeclaration)
+
+	assert.IsType(t, &ast.TupleType{}, pair.Type)
+	assert.IsType(t, &ast.TupleLiteral{}, pair.Value)
+
+	f := statements[1].(*ast.VariableDeclaration)
+
+	assert.IsType(t, &ast.FunctionType{}, f.Type)
+	assert.IsType(t, &ast.TupleType{}, f.Type.(*ast.FunctionType).ReturnType)
+
+	destructuring := statements[2].(*ast.DestructuringDeclaration)
+	pattern := destructuring.Pattern.(*ast.TuplePattern)
+
+	assert.Len(t, pattern.Elements, 2)
+	assert.IsType(t, &ast.TuplePattern{}, pattern.Elements[1])
+}
+
+func TestRecordPattern(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let { first, second: other } : NumberPair = pair
+		let { name: n, position: (x, y) } = user
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	annotated := statements[0].(*ast.DestructuringDeclaration)
+	pattern := annotated.Pattern.(*ast.RecordPattern)
+
+	assert.Equal(t, "NumberPair", annotated.Type.String())
+	assert.Len(t, pattern.Fields, 2)
+	assert.Equal(t, "first", pattern.Fields[0].Pattern.(*ast.BindingPattern).Name)
+	assert.Equal(t, "other", pattern.Fields[1].Pattern.(*ast.BindingPattern).Name)
+
+	inferred := statements[1].(*ast.DestructuringDeclaration)
+
+	assert.Nil(t, inferred.Type)
+	assert.IsType(t, &ast.TuplePattern{}, inferred.Pattern.(*ast.RecordPattern).Fields[1].Pattern)
+}
+
+func TestGenerics(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Pair<A, B> { first: A, second: B }
+		let identity : <T>(T) -> T = fn<T>(x: T): T => x
+		let pairs : [Pair<int, Box<string>>]?
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+	assert.Equal(t, []string{"A", "B"}, statements[0].(*ast.RecordDeclaration).TypeParameters)
+
+	identity := statements[1].(*ast.VariableDeclaration)
+
+	assert.Equal(t, "<T>(T) -> T", identity.Type.String())
+	assert.Equal(t, []string{"T"}, identity.Value.(*ast.FunctionExpression).TypeParameters)
+
+	pairs := statements[2].(*ast.VariableDeclaration).Type.(*ast.VariableType)
+
+	assert.Equal(t, "[Pair<int, Box<string>>]?", pairs.String())
+	assert.Len(t, pairs.SubType.(*ast.VariableType).Arguments, 2)
+}
+
+func TestInferredDeclarations(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let x = 100
+		let add = fn(a, b: int) => a + b
+		let y
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.False(t, ok)
+	assert.Len(t, statements, 3)
+
+	x := statements[0].(*ast.VariableDeclaration)
+
+	assert.Nil(t, x.Type)
+	assert.IsType(t, &ast.Literal{}, x.Value)
+
+	add := statements[1].(*ast.VariableDeclaration).Value.(*ast.FunctionExpression)
+
+	assert.Nil(t, add.ReturnType)
+	assert.Nil(t, add.Parameters[0].Type)
+	assert.Equal(t, "int", add.Parameters[1].Type.String())
+
+	// a declaration without a type needs a value to infer it from
+	assert.IsType(t, &ast.BadStatement{}, statements[2])
+}
+
 func TestRecordDeclaration(t *testing.T) {
-	lex := lexer.ScanTokens(`
-		struct Account {
+	lex := lexer.ScanText("test", `
+		type Account {
 			balance: int
 			credit_limit: int
 		}
 	`)
 
-	_, statements := parser.Parse(lex.Tokens)
+	_, statements := parser.Parse(lex, lex.Tokens)
 
 	if len(statements) != 1 {
 		t.Errorf("Expected %d statements, got %d.", 1, len(statements))
@@ -97,15 +237,92 @@ func TestRecordDeclaration(t *testing.T) {
 	assert.Contains(t, structDec.Record.Fields, "credit_limit")
 }
 
+func TestSumTypeDeclaration(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		type Shape = Circle { r: float } | Square { side: float } | Empty
+		type Option =
+			| Some { value: int }
+			| None
+		let circle : Shape = Circle { r: 1.5 }
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+	assert.Len(t, statements, 3)
+
+	shape := statements[0].(*ast.SumTypeDeclaration).Sum
+
+	assert.Equal(t, "Shape", shape.Name)
+	assert.Len(t, shape.Variants, 3)
+	assert.Equal(t, "Circle", shape.Variants[0].Name)
+	assert.Contains(t, shape.Variants[1].Record.Fields, "side")
+	assert.Empty(t, shape.Variants[2].Record.Fields)
+
+	option := statements[1].(*ast.SumTypeDeclaration).Sum
+
+	assert.Equal(t, "Some", option.Variants[0].Name)
+	assert.Equal(t, "None", option.Variants[1].Name)
+
+	circle := statements[2].(*ast.VariableDeclaration).Value.(*ast.RecordInstance)
+
+	assert.Equal(t, "Circle", circle.Name)
+}
+
+func TestMatchExpression(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		let area : float = match shape {
+			Circle { r } if r > 0.0 => r * r,
+			Square { side: s } => s * s,
+			Empty => 0.0,
+		}
+		let name : string = match (pair, values) {
+			(-1, [first, ..rest]) => "negative",
+			(_, []) => "empty",
+			(n, [..]) => "other"
+		}
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+	assert.Len(t, statements, 2)
+
+	area := statements[0].(*ast.VariableDeclaration).Value.(*ast.MatchExpression)
+
+	assert.IsType(t, &ast.VariableExpression{}, area.Value)
+	assert.Len(t, area.Arms, 3)
+	assert.NotNil(t, area.Arms[0].Guard)
+	assert.Equal(t, "Circle", area.Arms[0].Pattern.(*ast.VariantPattern).Name)
+	assert.Nil(t, area.Arms[1].Guard)
+	assert.IsType(t, &ast.BindingPattern{}, area.Arms[2].Pattern)
+
+	name := statements[1].(*ast.VariableDeclaration).Value.(*ast.MatchExpression)
+	first := name.Arms[0].Pattern.(*ast.TuplePattern)
+
+	assert.Equal(t, int64(-1), first.Elements[0].(*ast.LiteralPattern).Value)
+	assert.Len(t, first.Elements[1].(*ast.ArrayPattern).Elements, 1)
+	assert.Equal(t, "rest", first.Elements[1].(*ast.ArrayPattern).Rest.(*ast.BindingPattern).Name)
+
+	second := name.Arms[1].Pattern.(*ast.TuplePattern)
+
+	assert.IsType(t, &ast.WildcardPattern{}, second.Elements[0])
+	assert.Nil(t, second.Elements[1].(*ast.ArrayPattern).Rest)
+
+	third := name.Arms[2].Pattern.(*ast.TuplePattern)
+
+	assert.IsType(t, &ast.WildcardPattern{}, third.Elements[1].(*ast.ArrayPattern).Rest)
+}
+
 func TestBlockStatement(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		{
 			let x : int = 100
 			let y : int = 5
 		}
 	`)
 
-	_, statements := parser.Parse(lex.Tokens)
+	_, statements := parser.Parse(lex, lex.Tokens)
 
 	assert.IsType(t, &ast.BlockStatement{}, statements[0])
 
@@ -115,15 +332,15 @@ func TestBlockStatement(t *testing.T) {
 }
 
 func TestIfStatement(t *testing.T) {
-	lex := lexer.ScanTokens(`
-		if true {
+	lex := lexer.ScanText("test", `
+		if (true) {
 
 		} else {
 
 		}
 	`)
 
-	_, statements := parser.Parse(lex.Tokens)
+	_, statements := parser.Parse(lex, lex.Tokens)
 
 	assert.IsType(t, &ast.IfStatement{}, statements[0])
 
@@ -134,13 +351,13 @@ func TestIfStatement(t *testing.T) {
 }
 
 func TestWhileStatement(t *testing.T) {
-	lex := lexer.ScanTokens(`
-		while true {
+	lex := lexer.ScanText("test", `
+		while (true) {
 			print()
 		}
 	`)
 
-	_, statements := parser.Parse(lex.Tokens)
+	_, statements := parser.Parse(lex, lex.Tokens)
 
 	assert.IsType(t, &ast.WhileStatement{}, statements[0])
 
@@ -150,12 +367,61 @@ func TestWhileStatement(t *testing.T) {
 	assert.IsType(t, &ast.BlockStatement{}, whileStat.Body)
 }
 
+func TestForStatement(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		for (i in 0..=10) {
+			print(i)
+		}
+	`)
+
+	_, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.IsType(t, &ast.ForStatement{}, statements[0])
+
+	forStat := statements[0].(*ast.ForStatement)
+
+	assert.Equal(t, "i", forStat.Variable)
+	assert.IsType(t, &ast.RangeExpression{}, forStat.Iterable)
+	assert.True(t, forStat.Iterable.(*ast.RangeExpression).Inclusive)
+	assert.IsType(t, &ast.BlockStatement{}, forStat.Body)
+}
+
+func TestBreakContinue(t *testing.T) {
+	lex := lexer.ScanText("test", `
+		outer: while (true) {
+			for (i in 0..10) {
+				break outer
+				continue
+				i
+			}
+		}
+	`)
+
+	ok, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.True(t, ok)
+
+	whileStat := statements[0].(*ast.WhileStatement)
+
+	assert.Equal(t, "outer", whileStat.Label)
+
+	forStat := whileStat.Body.(*ast.BlockStatement).Statements[0].(*ast.ForStatement)
+	body := forStat.Body.(*ast.BlockStatement).Statements
+
+	assert.Equal(t, "", forStat.Label)
+	assert.Len(t, body, 3)
+	assert.Equal(t, "outer", body[0].(*ast.BreakStatement).Label)
+
+	// a label must be on the same line as the 'continue'
+	assert.Equal(t, "", body[1].(*ast.ContinueStatement).Label)
+}
+
 func TestReturnStatement(t *testing.T) {
-	lex := lexer.ScanTokens(`
+	lex := lexer.ScanText("test", `
 		return false
 	`)
 
-	_, statements := parser.Parse(lex.Tokens)
+	_, statements := parser.Parse(lex, lex.Tokens)
 
 	assert.IsType(t, &ast.ReturnStatement{}, statements[0])
 
@@ -164,3 +430,145 @@ func TestReturnStatement(t *testing.T) {
 	assert.NotNil(t, returnStat.Value)
 	assert.IsType(t, &ast.Literal{}, returnStat.Value)
 }
+
+func TestSpans(t *testing.T) {
+	lex := lexer.ScanText("test", `let total : int? = first + second
+type Pair {
+	first: int,
+	second: Missing
+}`)
+
+	_, statements := parser.Parse(lex, lex.Tokens)
+
+	assert.Len(t, statements, 2)
+
+	declaration := statements[0].(*ast.VariableDeclaration)
+	span := declaration.GetSpan()
+
+	assert.Equal(t, 1, span.Start.Line)
+	assert.Equal(t, 0, span.Start.Column)
+	assert.Equal(t, 33, span.End.Column)
+
+	typeSpan := declaration.Type.GetSpan()
+
+	assert.Equal(t, 12, typeSpan.Start.Column)
+	assert.Equal(t, 16, typeSpan.End.Column)
+
+	binarySpan := declaration.Value.GetSpan()
+
+	assert.Equal(t, 19, binarySpan.Start.Column)
+	assert.Equal(t, 33, binarySpan.End.Column)
+
+	// type errors point at the offending annotation rather than line 0
+	err := typechecker.CreateTypeChecker().CheckStatement(statements[1])
+	typeErr, isTypeErr := err.(*typechecker.TypeError)
+
+	assert.True(t, isTypeErr)
+	assert.Equal(t, 4, typeErr.GetSpan().Start.Line)
+	assert.Equal(t, 9, typeErr.GetSpan().Start.Column)
+}
+
+func TestErrorRecovery(t *testing.T) {
+	lex := lexer.ScanText("test", `let a : int = 1 +
+let b : string = "ok"
+while (true) {
+	let c : int = )
+	let d : int = Point { x: 1 *, y: 2 }
+	let e : int = 4
+}
+let f : int = 5`)
+
+	statements, errors := parser.ParseProgram(lex, lex.Tokens)
+
+	assert.Len(t, errors, 3)
+	assert.Equal(t, 2, errors[0].GetSpan().Start.Line)
+	assert.Equal(t, 4, errors[1].GetSpan().Start.Line)
+	assert.Equal(t, 5, errors[2].GetSpan().Start.Line)
+
+	assert.Len(t, statements, 4)
+	assert.IsType(t, &ast.BadStatement{}, statements[0])
+	assert.IsType(t, &ast.VariableDeclaration{}, statements[1])
+	assert.IsType(t, &ast.VariableDeclaration{}, statements[3])
+
+	// the block keeps its valid statements after the bad ones
+	body := statements[2].(*ast.WhileStatement).Body.(*ast.BlockStatement)
+
+	assert.Len(t, body.Statements, 3)
+	assert.IsType(t, &ast.BadStatement{}, body.Statements[0])
+	assert.IsType(t, &ast.BadStatement{}, body.Statements[1])
+	assert.IsType(t, &ast.VariableDeclaration{}, body.Statements[2])
+
+	// the valid parts of the program still type check
+	assert.True(t, typechecker.CreateTypeChecker().CheckAll(statements))
+}
+
+func TestRecoveryFromUnclosedBrace(t *testing.T) {
+	lex := lexer.ScanText("test", `type P { x: int, y: int }
+let p = P { x: 1, y:
+let q : int = "two"
+let @r : int = 3
+let s : int = 4`)
+
+	statements, errors := parser.ParseProgram(lex, lex.Tokens)
+
+	// the invalid '@' was already reported by the lexer
+	assert.Len(t, lex.Errors, 1)
+	assert.Len(t, errors, 1)
+	assert.Equal(t, 3, errors[0].GetSpan().Start.Line)
+
+	assert.Len(t, statements, 5)
+	assert.IsType(t, &ast.BadStatement{}, statements[1])
+	assert.IsType(t, &ast.VariableDeclaration{}, statements[2])
+	assert.IsType(t, &ast.BadStatement{}, statements[3])
+	assert.IsType(t, &ast.VariableDeclaration{}, statements[4])
+
+	// statements after the unclosed brace are still checked
+	tc := typechecker.CreateTypeChecker()
+
+	assert.False(t, tc.CheckAll(statements))
+	assert.Len(t, tc.Errors, 1)
+	assert.Equal(t, 3, tc.Errors[0].GetSpan().Start.Line)
+}
+
+func TestRecoveryAtLineBreak(t *testing.T) {
+	lex := lexer.ScanText("test", `let x : int = 1
+let y : int = )
+x = 2
+x + 1`)
+
+	statements, errors := parser.ParseProgram(lex, lex.Tokens)
+
+	assert.Len(t, errors, 1)
+	assert.Equal(t, 2, errors[0].GetSpan().Start.Line)
+
+	assert.Len(t, statements, 4)
+	assert.IsType(t, &ast.BadStatement{}, statements[1])
+	assert.IsType(t, &ast.ExpressionStatement{}, statements[2])
+	assert.IsType(t, &ast.ExpressionStatement{}, statements[3])
+
+	// the assignment after the syntax error is still checked
+	tc := typechecker.CreateTypeChecker()
+
+	assert.False(t, tc.CheckAll(statements))
+	assert.Len(t, tc.Errors, 1)
+	assert.Contains(t, tc.Errors[0].Error(), "Cannot assign twice to immutable variable 'x'.")
+}
+
+func TestUnexpectedEndOfInput(t *testing.T) {
+	inputs := []string{
+		"sum(", "{", "let x :", "type Point {", "Point { x:",
+		"while (true)", "if (true)", "if (true) {} else", "for (i in xs)", "let f = fn() =>",
+	}
+
+	for _, input := range inputs {
+		lex := lexer.ScanText("test", input)
+		statements, errors := parser.ParseProgram(lex, lex.Tokens)
+
+		assert.Len(t, errors, 1, input)
+		assert.Contains(t, errors[0].Error(), "Unexpected end of input", input)
+		assert.Equal(t, len(input), errors[0].GetSpan().Start.Column, input)
+
+		// nothing with a missing body reaches the type checker
+		assert.True(t, typechecker.CreateTypeChecker().CheckAll(statements), input)
+	}
+}
@@ -1,16 +1,80 @@
 package typechecker
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
+)
+
+/*
+Returned when checking a node that failed to parse. The syntax error has
+already been reported, so this is not collected as a TypeError.
+*/
+var errSyntax = errors.New("expression contains a syntax error")
+
+/*
+Returned when using a variable whose declaration failed to type check. The
+declaration has already been reported, so this is not collected either.
+*/
+var errInvalidDeclaration = errors.New("variable has an invalid declaration")
 
 type TypeError struct {
-	message string
-	line    int
+	code     string
+	message  string
+	span     io.Span
+	severity diagnostics.Severity
+	labels   []diagnostics.Label
+	notes    []string
+}
+
+func CreateTypeError(code string, message string, span io.Span) *TypeError {
+	return &TypeError{code: code, message: message, span: span, severity: diagnostics.Error}
 }
 
-func CreateTypeError(message string, line int) *TypeError {
-	return &TypeError{message, line}
+/*
+Creates a problem which doesn't stop the program from type checking, i.e.
+an unreachable arm of a match expression.
+*/
+func CreateTypeWarning(code string, message string, span io.Span) *TypeError {
+	return &TypeError{code: code, message: message, span: span, severity: diagnostics.Warning}
 }
 
 func (t *TypeError) Error() string {
-	return fmt.Sprintf("[type] line %d, %s\n", t.line, t.message)
+	return fmt.Sprintf("[type] line %d:%d, %s\n", t.span.Start.Line, t.span.Start.Column+1, t.message)
+}
+
+func (t *TypeError) GetSpan() io.Span {
+	return t.span
+}
+
+/*
+Points at another location that explains the error, i.e. where a
+variable was declared.
+*/
+func (t *TypeError) WithLabel(span io.Span, message string) *TypeError {
+	t.labels = append(t.labels, diagnostics.Label{Span: span, Message: message})
+
+	return t
+}
+
+func (t *TypeError) WithNote(note string) *TypeError {
+	t.notes = append(t.notes, note)
+
+	return t
+}
+
+func (t *TypeError) Diagnostic() *diagnostics.Diagnostic {
+	diagnostic := diagnostics.CreateDiagnostic(t.severity, t.code, t.message, t.span)
+
+	for _, label := range t.labels {
+		diagnostic.WithLabel(label.Span, label.Message)
+	}
+
+	for _, note := range t.notes {
+		diagnostic.WithNote(note)
+	}
+
+	return diagnostic
 }
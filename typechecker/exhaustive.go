@@ -0,0 +1,549 @@
+package typechecker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/lexer"
+)
+
+/*
+Exhaustiveness and redundancy checking for patterns, based on the usefulness
+algorithm from "Warnings for pattern matching" (Maranget, 2007).
+
+Patterns are first lowered into spaces, which are either a wildcard or a
+constructor applied to spaces for each of its arguments. Tuples and records
+have a single constructor, sum types have one per variant, booleans have
+true and false, and optionals have none and some. Every other literal is a
+constructor of a type with infinitely many values, so it can only be covered
+by a wildcard.
+*/
+
+type constructorKind int
+
+const (
+	variantConstructor constructorKind = iota
+	tupleConstructor
+	recordConstructor
+	arrayConstructor
+	boolConstructor
+	noneConstructor
+	someConstructor
+	literalConstructor
+)
+
+type constructor struct {
+	kind constructorKind
+
+	// the variant, literal or boolean value
+	name string
+
+	// names of the fields of records and variants, sorted so that they line up with the arguments
+	fields []string
+
+	// the number of elements of an array, which is the minimum if it is open, i.e. [a, ..rest]
+	length int
+	open   bool
+
+	arity int
+}
+
+func (c *constructor) key() string {
+	return fmt.Sprintf("%d:%s:%d:%t", c.kind, c.name, c.length, c.open)
+}
+
+/*
+Either a wildcard, if the constructor is nil, or a constructor applied to
+the spaces of its arguments.
+*/
+type space struct {
+	constructor *constructor
+	args        []*space
+}
+
+var wildcard = &space{}
+
+func wildcards(count int) []*space {
+	spaces := make([]*space, count)
+
+	for i := range spaces {
+		spaces[i] = wildcard
+	}
+
+	return spaces
+}
+
+func sortedFields(fields map[string]ast.Type) []string {
+	names := make([]string, 0, len(fields))
+
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+/*
+Lowers a pattern which has already been checked against the type.
+*/
+func (tc *TypeChecker) lower(pattern ast.Pattern, patternType ast.Type) *space {
+	switch target := pattern.(type) {
+	case *ast.BindingPattern:
+		if sum := tc.findSumType(patternType); sum != nil {
+			if variant := sum.FindVariant(target.Name); variant != nil {
+				return &space{
+					constructor: variantFor(variant),
+					args:        wildcards(len(variant.Record.Fields)),
+				}
+			}
+		}
+	case *ast.LiteralPattern:
+		if target.LiteralType == lexer.NULL {
+			return &space{constructor: &constructor{kind: noneConstructor, name: "none"}}
+		}
+
+		if isOptional(patternType) {
+			return &space{
+				constructor: &constructor{kind: someConstructor, name: "some", arity: 1},
+				args:        []*space{tc.lower(pattern, requiredType(patternType))},
+			}
+		}
+
+		if target.LiteralType == lexer.BOOL {
+			return &space{constructor: &constructor{kind: boolConstructor, name: fmt.Sprint(target.Value)}}
+		}
+
+		name := fmt.Sprint(target.Value)
+
+		if value, isString := target.Value.(string); isString {
+			name = strconv.Quote(value)
+		}
+
+		return &space{constructor: &constructor{kind: literalConstructor, name: name}}
+	case *ast.TuplePattern:
+		tupleType := patternType.(*ast.TupleType)
+		args := make([]*space, len(target.Elements))
+
+		for i, element := range target.Elements {
+			args[i] = tc.lower(element, tupleType.Elements[i])
+		}
+
+		return &space{
+			constructor: &constructor{kind: tupleConstructor, arity: len(args)},
+			args:        args,
+		}
+	case *ast.RecordPattern:
+		fields, _ := tc.recordFields(patternType, target.Span)
+		names := sortedFields(fields)
+
+		return &space{
+			constructor: &constructor{kind: recordConstructor, fields: names, arity: len(names)},
+			args:        tc.lowerFields(target.Fields, fields, names),
+		}
+	case *ast.VariantPattern:
+		variant := tc.findSumType(patternType).FindVariant(target.Name)
+		variantConstructor := variantFor(variant)
+
+		return &space{
+			constructor: variantConstructor,
+			args:        tc.lowerFields(target.Fields, variant.Record.Fields, variantConstructor.fields),
+		}
+	case *ast.ArrayPattern:
+		elementType := patternType.(*ast.VariableType).SubType
+		args := make([]*space, len(target.Elements))
+
+		for i, element := range target.Elements {
+			args[i] = tc.lower(element, elementType)
+		}
+
+		return &space{
+			constructor: &constructor{
+				kind:   arrayConstructor,
+				length: len(args),
+				open:   target.Rest != nil,
+				arity:  len(args),
+			},
+			args: args,
+		}
+	}
+
+	return wildcard
+}
+
+func (tc *TypeChecker) lowerFields(
+	patterns []ast.RecordPatternField,
+	fields map[string]ast.Type,
+	names []string,
+) []*space {
+	args := wildcards(len(names))
+
+	for _, field := range patterns {
+		position := sort.SearchStrings(names, field.Name)
+		args[position] = tc.lower(field.Pattern, fields[field.Name])
+	}
+
+	return args
+}
+
+func variantFor(variant *ast.Variant) *constructor {
+	names := sortedFields(variant.Record.Fields)
+
+	return &constructor{kind: variantConstructor, name: variant.Name, fields: names, arity: len(names)}
+}
+
+/*
+Returns the types of the arguments of a constructor of the given type.
+*/
+func (tc *TypeChecker) argumentTypes(c *constructor, parentType ast.Type) []ast.Type {
+	switch c.kind {
+	case tupleConstructor:
+		return parentType.(*ast.TupleType).Elements
+	case recordConstructor:
+		fields, _ := tc.recordFields(parentType, parentType.GetSpan())
+
+		return fieldTypes(fields, c.fields)
+	case variantConstructor:
+		variant := tc.findSumType(parentType).FindVariant(c.name)
+
+		return fieldTypes(variant.Record.Fields, c.fields)
+	case arrayConstructor:
+		types := make([]ast.Type, c.length)
+
+		for i := range types {
+			types[i] = parentType.(*ast.VariableType).SubType
+		}
+
+		return types
+	case someConstructor:
+		return []ast.Type{requiredType(parentType)}
+	}
+
+	return nil
+}
+
+func fieldTypes(fields map[string]ast.Type, names []string) []ast.Type {
+	types := make([]ast.Type, len(names))
+
+	for i, name := range names {
+		types[i] = fields[name]
+	}
+
+	return types
+}
+
+/*
+Returns every constructor of a type if there are finitely many of them, given
+the constructors used by the patterns in the first column. Arrays may have any
+length, but every length beyond the longest pattern is matched by the same
+rows, so those lengths are covered by a single open constructor.
+*/
+func (tc *TypeChecker) signature(signatureType ast.Type, used []*constructor) ([]*constructor, bool) {
+	if isOptional(signatureType) {
+		return []*constructor{
+			{kind: noneConstructor, name: "none"},
+			{kind: someConstructor, name: "some", arity: 1},
+		}, true
+	}
+
+	if sum := tc.findSumType(signatureType); sum != nil {
+		constructors := make([]*constructor, len(sum.Variants))
+
+		for i, variant := range sum.Variants {
+			constructors[i] = variantFor(variant)
+		}
+
+		return constructors, true
+	}
+
+	if tc.match(signatureType, ast.CreateTypeFromLiteral(lexer.BOOL)) {
+		return []*constructor{{kind: boolConstructor, name: "true"}, {kind: boolConstructor, name: "false"}}, true
+	}
+
+	switch used[0].kind {
+	case tupleConstructor, recordConstructor:
+		return []*constructor{used[0]}, true
+	case arrayConstructor:
+		longest := 0
+
+		for _, c := range used {
+			length := c.length
+
+			if !c.open {
+				length++
+			}
+
+			if length > longest {
+				longest = length
+			}
+		}
+
+		constructors := make([]*constructor, longest+1)
+
+		for length := range constructors {
+			constructors[length] = &constructor{
+				kind:   arrayConstructor,
+				length: length,
+				open:   length == longest,
+				arity:  length,
+			}
+		}
+
+		return constructors, true
+	}
+
+	return nil, false
+}
+
+/*
+Returns the arguments of the row's first space if it matches the constructor
+followed by the rest of the row, or false if the row can't match it.
+*/
+func specialize(row []*space, c *constructor) ([]*space, bool) {
+	head := row[0]
+	var args []*space
+
+	switch {
+	case head.constructor == nil:
+		args = wildcards(c.arity)
+	case c.kind == arrayConstructor:
+		// an open pattern, i.e. [a, ..rest], matches every array at least as long as it
+		if head.constructor.length > c.length || (!head.constructor.open && head.constructor.length != c.length) {
+			return nil, false
+		}
+
+		args = append(append([]*space{}, head.args...), wildcards(c.length-head.constructor.length)...)
+	case head.constructor.key() == c.key():
+		args = head.args
+	default:
+		return nil, false
+	}
+
+	return append(append([]*space{}, args...), row[1:]...), true
+}
+
+/*
+Returns the patterns which are matched by the vector but none of the rows, or
+an empty list if the vector is not useful since every value it matches is
+already matched by a row. The types are those of each column.
+*/
+func (tc *TypeChecker) useful(rows [][]*space, vector []*space, types []ast.Type) [][]*space {
+	if len(vector) == 0 {
+		if len(rows) == 0 {
+			return [][]*space{{}}
+		}
+
+		return nil
+	}
+
+	head := vector[0]
+
+	if head.constructor != nil && head.constructor.kind != arrayConstructor {
+		return tc.usefulConstructor(rows, vector, types, head.constructor)
+	}
+
+	used := make([]*constructor, 0)
+
+	for _, row := range rows {
+		if row[0].constructor != nil {
+			used = append(used, row[0].constructor)
+		}
+	}
+
+	// an open array pattern covers several lengths, so it is split into each of them
+	if head.constructor != nil {
+		used = append(used, head.constructor)
+	}
+
+	if len(used) > 0 {
+		if constructors, isFinite := tc.signature(types[0], used); isFinite {
+			witnesses := make([][]*space, 0)
+
+			// constructors which the vector doesn't match are skipped by usefulConstructor
+			for _, c := range constructors {
+				witnesses = append(witnesses, tc.usefulConstructor(rows, vector, types, c)...)
+			}
+
+			return witnesses
+		}
+	}
+
+	// otherwise, only the rows which start with a wildcard can match values that no constructor covers
+	defaults := make([][]*space, 0)
+
+	for _, row := range rows {
+		if row[0].constructor == nil {
+			defaults = append(defaults, row[1:])
+		}
+	}
+
+	witnesses := tc.useful(defaults, vector[1:], types[1:])
+
+	for i, witness := range witnesses {
+		witnesses[i] = append([]*space{wildcard}, witness...)
+	}
+
+	return witnesses
+}
+
+func (tc *TypeChecker) usefulConstructor(
+	rows [][]*space,
+	vector []*space,
+	types []ast.Type,
+	c *constructor,
+) [][]*space {
+	specialized := make([][]*space, 0)
+
+	for _, row := range rows {
+		if specializedRow, matches := specialize(row, c); matches {
+			specialized = append(specialized, specializedRow)
+		}
+	}
+
+	specializedVector, matches := specialize(vector, c)
+
+	if !matches {
+		return nil
+	}
+
+	argumentTypes := append(tc.argumentTypes(c, types[0]), types[1:]...)
+	witnesses := tc.useful(specialized, specializedVector, argumentTypes)
+
+	// rebuild the constructor from the first witnesses, which are its arguments
+	for i, witness := range witnesses {
+		rebuilt := &space{constructor: c, args: witness[:c.arity]}
+		witnesses[i] = append([]*space{rebuilt}, witness[c.arity:]...)
+	}
+
+	return witnesses
+}
+
+/*
+Returns the patterns which none of the given patterns match, i.e. the cases
+that are missing from a match expression.
+*/
+func (tc *TypeChecker) missingCases(patterns []ast.Pattern, patternType ast.Type) []string {
+	// binding the patterns may have inferred parts of the type
+	patternType = ast.Resolve(patternType)
+	rows := make([][]*space, len(patterns))
+
+	for i, pattern := range patterns {
+		rows[i] = []*space{tc.lower(pattern, patternType)}
+	}
+
+	witnesses := tc.useful(rows, []*space{wildcard}, []ast.Type{patternType})
+	missing := make([]string, len(witnesses))
+
+	for i, witness := range witnesses {
+		missing[i] = witness[0].String()
+	}
+
+	return missing
+}
+
+/*
+Checks that a match expression covers every value, and warns about arms
+which can never be reached. Arms with guards may not match, so they are
+not counted as covering anything.
+*/
+func (tc *TypeChecker) checkExhaustive(expr *ast.MatchExpression, valueType ast.Type) error {
+	// binding the patterns may have inferred parts of the type
+	valueType = ast.Resolve(valueType)
+	rows := make([][]*space, 0)
+	covered := make([]ast.Pattern, 0)
+
+	for _, arm := range expr.Arms {
+		row := []*space{tc.lower(arm.Pattern, valueType)}
+
+		if len(tc.useful(rows, row, []ast.Type{valueType})) == 0 {
+			tc.warn(
+				CreateTypeWarning(diagnostics.UnreachablePatternCode, "Unreachable match arm.", arm.Pattern.GetSpan()).
+					WithNote("every value matched by this pattern is matched by an earlier arm"),
+			)
+		}
+
+		if arm.Guard == nil {
+			rows = append(rows, row)
+			covered = append(covered, arm.Pattern)
+		}
+	}
+
+	missing := tc.missingCases(covered, valueType)
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	typeErr := CreateTypeError(
+		diagnostics.NonExhaustiveCode,
+		fmt.Sprintf("Match expression does not cover every value of type %s.", valueType.String()),
+		expr.Span,
+	)
+
+	for _, pattern := range missing {
+		typeErr.WithNote(fmt.Sprintf("missing case: %s", pattern))
+	}
+
+	return typeErr
+}
+
+func (s *space) String() string {
+	if s.constructor == nil {
+		return "_"
+	}
+
+	c := s.constructor
+	args := make([]string, len(s.args))
+
+	for i, arg := range s.args {
+		args[i] = arg.String()
+	}
+
+	switch c.kind {
+	case variantConstructor:
+		if c.arity == 0 {
+			return c.name
+		}
+
+		return fmt.Sprintf("%s %s", c.name, fieldsString(c.fields, args))
+	case recordConstructor:
+		return fieldsString(c.fields, args)
+	case tupleConstructor:
+		return fmt.Sprintf("(%s)", strings.Join(args, ", "))
+	case arrayConstructor:
+		if c.open {
+			args = append(args, "..")
+		}
+
+		return fmt.Sprintf("[%s]", strings.Join(args, ", "))
+	case someConstructor:
+		return args[0]
+	}
+
+	return c.name
+}
+
+/*
+Lists the fields which are matched by something other than a wildcard,
+i.e. { r: 0.0, .. }.
+*/
+func fieldsString(fields []string, args []string) string {
+	matched := make([]string, 0)
+
+	for i, arg := range args {
+		if arg != "_" {
+			matched = append(matched, fmt.Sprintf("%s: %s", fields[i], arg))
+		}
+	}
+
+	if len(matched) < len(fields) {
+		matched = append(matched, "..")
+	}
+
+	return fmt.Sprintf("{ %s }", strings.Join(matched, ", "))
+}
@@ -2,9 +2,12 @@ package typechecker
 
 import (
 	"fmt"
+	"strconv"
 
-	"github.com/fatih/color"
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/context"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
 	"github.com/gmisail/glamlang/lexer"
 	"github.com/martinusso/inflect"
 )
@@ -14,48 +17,127 @@ Checks the type of the expression and, if valid, returns true and its type. If t
 was an error while type checking, it will return false, nil.
 */
 func (tc *TypeChecker) CheckExpression(expr ast.Expression) (ast.Type, error) {
+	exprType, err := tc.checkExpression(expr)
+
+	if exprType == nil {
+		return nil, err
+	}
+
+	// type variables which have already been inferred are replaced with their type
+	return ast.Resolve(exprType), err
+}
+
+func (tc *TypeChecker) checkExpression(expr ast.Expression) (ast.Type, error) {
 	switch exprType := expr.(type) {
 	case *ast.Literal:
 		literalType := ast.CreateTypeFromLiteral(exprType.LiteralType)
+
+		// none is a value of any optional type, i.e. int? in let o : int? = none
+		if exprType.LiteralType == lexer.NULL {
+			literalType = ast.MakeOptional(tc.freshVariable())
+		}
+
 		exprType.Type = literalType
 
 		// literals always check successfully
 		return literalType, nil
 	case *ast.VariableExpression:
-		targetExists, targetType := tc.context.FindVariable(exprType.Value)
+		targetExists, binding := tc.context.FindBinding(exprType.Value)
+
+		// variants without fields are constructed by name alone, i.e. Empty
+		if isConstructor, sum := tc.context.FindConstructor(exprType.Value); !targetExists && isConstructor {
+			return tc.checkConstructor(exprType, sum, exprType.Value, nil)
+		}
 
 		if !targetExists {
 			return nil, CreateTypeError(
+				diagnostics.UndefinedNameCode,
 				fmt.Sprintf("Undefined variable '%s'.", exprType.Value),
-				exprType.Line,
+				exprType.Span,
 			)
 		}
 
-		return *targetType, nil
+		if binding.Invalid {
+			return nil, errInvalidDeclaration
+		}
+
+		exprType.Type = *binding.Type
+
+		return *binding.Type, nil
 	case *ast.FunctionExpression:
 		// validate that the body of the function is valid
 		tc.context.EnterScope()
 
+		if typeParameterErr := tc.addTypeParameters(exprType.TypeParameters, exprType.Span); typeParameterErr != nil {
+			tc.context.ExitScope()
+
+			return nil, typeParameterErr
+		}
+
+		// loops outside of the function can't be exited from within it
+		enclosingLoops := tc.loops
+		tc.loops = nil
+
+		defer func() { tc.loops = enclosingLoops }()
+
 		parameters := make([]ast.Type, len(exprType.Parameters))
 
 		// push the parameters into scope
 		for i, param := range exprType.Parameters {
 			paramType := param.Type
+
+			// parameters without a type annotation are inferred from the body, i.e. fn(x) => x + 1
+			if paramType == nil {
+				paramType = tc.freshVariable()
+			}
+
 			parameters[i] = paramType
 
-			if !tc.context.Add(param.Name, &paramType) {
-				color.Red("Variable '%s' already exists in this scope.", param.Name)
+			if keyErr := tc.checkMapKeys(paramType); keyErr != nil {
+				tc.context.ExitScope()
+
+				return nil, keyErr
+			}
+
+			if argumentErr := tc.checkTypeArguments(paramType); argumentErr != nil {
+				tc.context.ExitScope()
+
+				return nil, argumentErr
 			}
-		}
 
-		bodyErr := tc.CheckStatement(exprType.Body)
+			if !tc.context.Add(param.Name, context.CreateBinding(&paramType, param.Mutable, context.ParameterBinding, param.Span)) {
+				tc.context.ExitScope()
 
-		if bodyErr != nil {
+				return nil, CreateTypeError(
+					diagnostics.DuplicateNameCode,
+					fmt.Sprintf("Variable '%s' already exists in this scope.", param.Name),
+					param.Span,
+				)
+			}
+		}
+
+		if argumentErr := tc.checkTypeArguments(exprType.ReturnType); argumentErr != nil {
 			tc.context.ExitScope()
-			return nil, bodyErr
+
+			return nil, argumentErr
+		}
+
+		// an expression body is checked along with the return type, checking it twice would repeat its warnings
+		if _, isExpression := exprType.Body.(*ast.ExpressionStatement); !isExpression {
+			bodyErr := tc.CheckStatement(exprType.Body)
+
+			if bodyErr != nil {
+				tc.context.ExitScope()
+				return nil, bodyErr
+			}
 		}
 
 		returnType := exprType.ReturnType
+
+		if returnType == nil {
+			returnType = tc.freshVariable()
+		}
+
 		hasReturn, returnErr := tc.checkLastReturnStatement(returnType, exprType.Body)
 
 		if !hasReturn || returnErr != nil {
@@ -77,7 +159,11 @@ func (tc *TypeChecker) CheckExpression(expr ast.Expression) (ast.Type, error) {
 
 		tc.context.ExitScope()
 
-		return &ast.FunctionType{Parameters: parameters, ReturnType: exprType.ReturnType}, nil
+		return &ast.FunctionType{
+			TypeParameters: exprType.TypeParameters,
+			Parameters:     parameters,
+			ReturnType:     returnType,
+		}, nil
 	case *ast.FunctionCall:
 		return tc.checkFunctionCall(exprType)
 	case *ast.Group:
@@ -92,6 +178,22 @@ func (tc *TypeChecker) CheckExpression(expr ast.Expression) (ast.Type, error) {
 		return tc.checkGetExpression(exprType)
 	case *ast.RecordInstance:
 		return tc.checkRecordInstance(exprType)
+	case *ast.Assignment:
+		return tc.checkAssignment(exprType)
+	case *ast.ArrayLiteral:
+		return tc.checkArrayLiteral(exprType)
+	case *ast.MapLiteral:
+		return tc.checkMapLiteral(exprType)
+	case *ast.TupleLiteral:
+		return tc.checkTupleLiteral(exprType)
+	case *ast.IndexExpression:
+		return tc.checkIndexExpression(exprType)
+	case *ast.MatchExpression:
+		return tc.checkMatchExpression(exprType)
+	case *ast.BadExpression:
+		return nil, errSyntax
+	case *ast.Interpolation:
+		return tc.checkInterpolation(exprType)
 	}
 
 	return nil, nil
@@ -103,14 +205,28 @@ func (tc *TypeChecker) CheckExpression(expr ast.Expression) (ast.Type, error) {
  * For instance, "User" --> { name: string, bio: string }
  */
 func (tc *TypeChecker) resolve(record ast.RecordType) ast.Type {
+	return tc.resolveRecord(record, make(map[string]bool))
+}
+
+/*
+Records which are already being expanded are kept by name, otherwise a record
+which refers to itself would be expanded forever.
+*/
+func (tc *TypeChecker) resolveRecord(record ast.RecordType, expanding map[string]bool) ast.Type {
 	resolvedFields := make(map[string]ast.Type)
 
 	for field, fieldType := range record.Fields {
-		if subRecord, ok := fieldType.(*ast.VariableType); ok {
-			isRecord, recordFields := tc.context.FindType(subRecord.Base)
+		if subRecord, ok := fieldType.(*ast.VariableType); ok && !expanding[subRecord.Base] {
+			isRecord, recordFields := tc.findRecord(subRecord)
 
 			if isRecord {
-				resolvedFields[field] = tc.resolve(*recordFields)
+				expanding[subRecord.Base] = true
+				resolvedFields[field] = tc.resolveRecord(*recordFields, expanding)
+				delete(expanding, subRecord.Base)
+
+				if subRecord.Optional {
+					resolvedFields[field] = ast.MakeOptional(resolvedFields[field])
+				}
 
 				continue
 			}
@@ -120,37 +236,152 @@ func (tc *TypeChecker) resolve(record ast.RecordType) ast.Type {
 		resolvedFields[field] = fieldType
 	}
 
-	return &ast.RecordType{Fields: resolvedFields}
+	return &ast.RecordType{Fields: resolvedFields, Optional: record.Optional}
 }
 
 /**
  * Checks if two types match. Prior to comparing the types, it will
  * resolve the type in case it is a user-defined record.
+ *
+ * Type variables are unified with the type they are matched against, so
+ * matching 'a with int infers 'a to be int. The first type is the one that
+ * is expected, so a generic function can be given where a function is
+ * expected but not the other way around.
  */
 func (tc *TypeChecker) match(first ast.Type, second ast.Type) bool {
+	first = ast.Prune(first)
+	second = ast.Prune(second)
+
+	if variable, isVariable := first.(*ast.TypeVariable); isVariable {
+		return tc.bind(variable, second)
+	}
+
+	if variable, isVariable := second.(*ast.TypeVariable); isVariable {
+		return tc.bind(variable, first)
+	}
+
 	firstType := first
 	secondType := second
 
+	firstFunction, firstIsFunction := first.(*ast.FunctionType)
+	secondFunction, secondIsFunction := second.(*ast.FunctionType)
+
+	if firstIsFunction && secondIsFunction {
+		if firstFunction.Optional != secondFunction.Optional {
+			return false
+		}
+
+		// a generic function can be used as any of its instances, i.e. <T>(T) -> T as (int) -> int
+		if len(firstFunction.TypeParameters) == 0 && len(secondFunction.TypeParameters) > 0 {
+			secondFunction = tc.instantiate(secondFunction)
+		}
+
+		if len(firstFunction.TypeParameters) > 0 || len(secondFunction.TypeParameters) > 0 {
+			return firstFunction.Equals(secondFunction)
+		}
+
+		if len(firstFunction.Parameters) != len(secondFunction.Parameters) {
+			return false
+		}
+
+		for i, param := range firstFunction.Parameters {
+			if !tc.match(param, secondFunction.Parameters[i]) {
+				return false
+			}
+		}
+
+		return tc.match(firstFunction.ReturnType, secondFunction.ReturnType)
+	}
+
+	// records within arrays need to be resolved as well, i.e. [User] and [{ name: string }]
+	firstArray, firstIsArray := first.(*ast.VariableType)
+	secondArray, secondIsArray := second.(*ast.VariableType)
+
+	if firstIsArray && secondIsArray && firstArray.IsArray() && secondArray.IsArray() {
+		if firstArray.Optional != secondArray.Optional {
+			return false
+		}
+
+		return tc.match(firstArray.SubType, secondArray.SubType)
+	}
+
+	firstTuple, firstIsTuple := first.(*ast.TupleType)
+	secondTuple, secondIsTuple := second.(*ast.TupleType)
+
+	if firstIsTuple && secondIsTuple {
+		if firstTuple.Optional != secondTuple.Optional || len(firstTuple.Elements) != len(secondTuple.Elements) {
+			return false
+		}
+
+		for i, element := range firstTuple.Elements {
+			if !tc.match(element, secondTuple.Elements[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	firstMap, firstIsMap := first.(*ast.MapType)
+	secondMap, secondIsMap := second.(*ast.MapType)
+
+	if firstIsMap && secondIsMap {
+		if firstMap.Optional != secondMap.Optional {
+			return false
+		}
+
+		return tc.match(firstMap.Key, secondMap.Key) && tc.match(firstMap.Value, secondMap.Value)
+	}
+
 	if v, ok := first.(*ast.VariableType); ok {
-		isRecord, recordFields := tc.context.FindType(v.Base)
+		isRecord, recordFields := tc.findRecord(v)
 
 		if isRecord {
 			firstType = tc.resolve(*recordFields)
 		}
+	} else if r, ok := first.(*ast.RecordType); ok {
+		// fields of a record instance may refer to records by name
+		firstType = tc.resolve(*r)
 	}
 
 	if v, ok := second.(*ast.VariableType); ok {
-		isRecord, recordFields := tc.context.FindType(v.Base)
+		isRecord, recordFields := tc.findRecord(v)
 
 		if isRecord {
 			secondType = tc.resolve(*recordFields)
 		}
+	} else if r, ok := second.(*ast.RecordType); ok {
+		secondType = tc.resolve(*r)
+	}
+
+	firstRecord, firstIsRecord := firstType.(*ast.RecordType)
+	secondRecord, secondIsRecord := secondType.(*ast.RecordType)
+
+	// every field of the first record has to be in the second, with a matching type
+	if firstIsRecord && secondIsRecord {
+		if isOptional(first) != isOptional(second) {
+			return false
+		}
+
+		for name, field := range firstRecord.Fields {
+			secondField, hasField := secondRecord.Fields[name]
+
+			if !hasField || !tc.match(field, secondField) {
+				return false
+			}
+		}
+
+		return true
 	}
 
 	return firstType.Equals(secondType)
 }
 
 func (tc *TypeChecker) checkRecordInstance(record *ast.RecordInstance) (ast.Type, error) {
+	if isConstructor, sum := tc.context.FindConstructor(record.Name); isConstructor {
+		return tc.checkConstructor(record, sum, record.Name, record.Values)
+	}
+
 	fields := make(map[string]ast.Type)
 
 	for field, fieldValue := range record.Values {
@@ -166,6 +397,186 @@ func (tc *TypeChecker) checkRecordInstance(record *ast.RecordInstance) (ast.Type
 	return &ast.RecordType{Fields: fields}, nil
 }
 
+/*
+Checks a call to the constructor of a variant, i.e. Circle { r: 1.0 }. Every
+field of the variant must be given exactly once with a value of the right type.
+*/
+func (tc *TypeChecker) checkConstructor(
+	expr ast.Expression,
+	sum *ast.SumType,
+	variantName string,
+	values map[string]ast.Expression,
+) (ast.Type, error) {
+	variant := sum.FindVariant(variantName)
+
+	for field, fieldValue := range values {
+		expectedType, fieldExists := variant.Record.Fields[field]
+
+		if !fieldExists {
+			message := fmt.Sprintf("Variant '%s' of '%s' has no field '%s'.", variantName, sum.Name, field)
+
+			return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.GetSpan())
+		}
+
+		fieldType, fieldErr := tc.CheckExpression(fieldValue)
+
+		if fieldErr != nil {
+			return nil, fieldErr
+		}
+
+		if !tc.match(expectedType, fieldType) {
+			message := fmt.Sprintf(
+				"Invalid type for field '%s' of variant '%s'. Expected %s but got %s.",
+				field,
+				variantName,
+				expectedType.String(),
+				fieldType.String(),
+			)
+
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, fieldValue.GetSpan()).
+				WithLabel(expectedType.GetSpan(), "expected due to this type")
+		}
+	}
+
+	for field := range variant.Record.Fields {
+		if _, fieldGiven := values[field]; !fieldGiven {
+			message := fmt.Sprintf("Missing field '%s' in variant '%s'.", field, variantName)
+
+			return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.GetSpan()).
+				WithNote(fmt.Sprintf("'%s' is declared as %s", variantName, variant.Record.String()))
+		}
+	}
+
+	switch target := expr.(type) {
+	case *ast.VariableExpression:
+		target.Type = sum
+	case *ast.RecordInstance:
+		target.Type = sum
+	}
+
+	return sum, nil
+}
+
+/*
+Checks the pattern of every arm against the matched value, binding the names
+in each pattern within the scope of its arm. The bodies of every arm must have
+the same type, which becomes the type of the match expression.
+*/
+func (tc *TypeChecker) checkMatchExpression(expr *ast.MatchExpression) (ast.Type, error) {
+	valueType, valueErr := tc.CheckExpression(expr.Value)
+
+	if valueErr != nil {
+		return nil, valueErr
+	}
+
+	var resultType ast.Type
+	var resultSpan io.Span
+
+	// once none has been matched, a name in a later arm is bound to the value inside of the optional
+	noneMatched := false
+
+	for _, arm := range expr.Arms {
+		armType := valueType
+
+		if _, isBinding := arm.Pattern.(*ast.BindingPattern); isBinding && noneMatched {
+			armType = requiredType(ast.Prune(valueType))
+		}
+
+		if literal, isLiteral := arm.Pattern.(*ast.LiteralPattern); isLiteral && arm.Guard == nil {
+			noneMatched = noneMatched || literal.LiteralType == lexer.NULL
+		}
+
+		tc.context.EnterScope()
+
+		bodyType, armErr := tc.checkMatchArm(arm, armType)
+
+		tc.context.ExitScope()
+
+		if armErr != nil {
+			return nil, armErr
+		}
+
+		if resultType == nil {
+			resultType = bodyType
+			resultSpan = arm.Body.GetSpan()
+
+			continue
+		}
+
+		if !tc.match(resultType, bodyType) {
+			message := fmt.Sprintf(
+				"Arms of match expression have different types. Expected %s but got %s.",
+				resultType.String(),
+				bodyType.String(),
+			)
+
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, arm.Body.GetSpan()).
+				WithLabel(resultSpan, "expected because of this arm")
+		}
+	}
+
+	if exhaustiveErr := tc.checkExhaustive(expr, valueType); exhaustiveErr != nil {
+		return nil, exhaustiveErr
+	}
+
+	expr.Type = resultType
+
+	return resultType, nil
+}
+
+func (tc *TypeChecker) checkMatchArm(arm *ast.MatchArm, valueType ast.Type) (ast.Type, error) {
+	if patternErr := tc.bindPattern(arm.Pattern, valueType, false, context.MatchBinding); patternErr != nil {
+		return nil, patternErr
+	}
+
+	if arm.Guard != nil {
+		guardType, guardErr := tc.CheckExpression(arm.Guard)
+
+		if guardErr != nil {
+			return nil, guardErr
+		}
+
+		if !tc.match(guardType, ast.CreateTypeFromLiteral(lexer.BOOL)) {
+			message := fmt.Sprintf("Expected guard of match arm to be boolean, got %s.", guardType.String())
+
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, arm.Guard.GetSpan())
+		}
+	}
+
+	return tc.CheckExpression(arm.Body)
+}
+
+/*
+Every expression within an interpolated string must be convertible to a string.
+*/
+func (tc *TypeChecker) checkInterpolation(expr *ast.Interpolation) (ast.Type, error) {
+	for _, part := range expr.Parts {
+		partType, partErr := tc.CheckExpression(part)
+
+		if partErr != nil {
+			return nil, partErr
+		}
+
+		if _, isUnknown := unknownType(partType); isUnknown {
+			return nil, ambiguousTypeError(part.GetSpan())
+		}
+
+		if !HasStringConversion(partType.String()) {
+			message := fmt.Sprintf(
+				"Cannot interpolate value of type %s into a string.",
+				partType.String(),
+			)
+
+			return nil, CreateTypeError(diagnostics.InvalidOperationCode, message, part.GetSpan())
+		}
+	}
+
+	stringType := ast.CreateTypeFromLiteral(lexer.STRING)
+	expr.Type = stringType
+
+	return stringType, nil
+}
+
 func (tc *TypeChecker) checkGetExpression(expr *ast.GetExpression) (ast.Type, error) {
 	parentType, parentErr := tc.CheckExpression(expr.Parent)
 
@@ -173,15 +584,34 @@ func (tc *TypeChecker) checkGetExpression(expr *ast.GetExpression) (ast.Type, er
 		return nil, parentErr
 	}
 
+	// records are structural, so the fields being accessed aren't enough to tell which record it is
+	if _, isUnknown := unknownType(parentType); isUnknown {
+		return nil, ambiguousTypeError(expr.Parent.GetSpan())
+	}
+
+	// the value may be missing, so it has to be matched on first
+	if isOptional(parentType) {
+		message := fmt.Sprintf("Cannot access field '%s' of optional type %s.", expr.Name, parentType.String())
+
+		return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.Span).
+			WithNote("match on the value to handle the case where it is none")
+	}
+
 	switch variableType := parentType.(type) {
 	case *ast.VariableType:
 		typeName := variableType.Base
-		typeExists, typeMembers := tc.context.FindType(typeName)
+
+		if isSum, sum := tc.context.FindSumType(typeName); isSum && !variableType.IsArray() {
+			return nil, sumFieldError(sum, expr)
+		}
+
+		typeExists, typeMembers := tc.findRecord(variableType)
 
 		if !typeExists {
 			return nil, CreateTypeError(
+				diagnostics.UndefinedNameCode,
 				fmt.Sprintf("Cannot access member variable from a non-existent type '%s'.", typeName),
-				expr.Line,
+				expr.Span,
 			)
 		}
 
@@ -194,20 +624,68 @@ func (tc *TypeChecker) checkGetExpression(expr *ast.GetExpression) (ast.Type, er
 				typeName,
 			)
 
-			return nil, CreateTypeError(message, expr.Line)
+			return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.Span)
 		}
 
 		expr.Type = memberType
 
 		return memberType, nil
+	case *ast.TupleType:
+		position, positionErr := strconv.Atoi(expr.Name)
+
+		if positionErr != nil || position >= len(variableType.Elements) {
+			message := fmt.Sprintf(
+				"Tuple of type %s has no field '%s'.",
+				variableType.String(),
+				expr.Name,
+			)
+
+			return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.Span)
+		}
+
+		expr.Type = variableType.Elements[position]
+
+		return expr.Type, nil
+	case *ast.RecordType:
+		memberType, memberExists := variableType.Fields[expr.Name]
+
+		if !memberExists {
+			message := fmt.Sprintf("Member variable '%s' does not exist on type %s.", expr.Name, variableType.String())
+
+			return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.Span)
+		}
+
+		expr.Type = memberType
+
+		return memberType, nil
+	case *ast.SumType:
+		return nil, sumFieldError(variableType, expr)
 	case *ast.FunctionType:
 		return nil, CreateTypeError(
+			diagnostics.InvalidFieldCode,
 			"Cannot access a member variable of a function type.",
-			expr.Line,
+			expr.Span,
 		)
+	case *ast.MapType:
+		message := fmt.Sprintf("Cannot access field '%s' of map type %s.", expr.Name, variableType.String())
+
+		return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.Span).
+			WithNote(fmt.Sprintf("use an index to look up a key, i.e. map[\"%s\"]", expr.Name))
 	}
 
-	return nil, nil
+	message := fmt.Sprintf("Cannot access field '%s' of type %s.", expr.Name, parentType.String())
+
+	return nil, CreateTypeError(diagnostics.InvalidFieldCode, message, expr.Span)
+}
+
+/*
+Fields of a sum type depend on its variant, so they can't be accessed directly.
+*/
+func sumFieldError(sum *ast.SumType, expr *ast.GetExpression) error {
+	message := fmt.Sprintf("Cannot access field '%s' of sum type '%s'.", expr.Name, sum.Name)
+
+	return CreateTypeError(diagnostics.InvalidFieldCode, message, expr.Span).
+		WithNote("the fields of a value depend on which variant it is")
 }
 
 func (tc *TypeChecker) checkFunctionCall(expr *ast.FunctionCall) (ast.Type, error) {
@@ -217,11 +695,29 @@ func (tc *TypeChecker) checkFunctionCall(expr *ast.FunctionCall) (ast.Type, erro
 		return nil, calleeErr
 	}
 
+	// calling a value whose type is unknown infers it to be a function, i.e. f in fn(f) => f(1)
+	if variable, isUnknown := unknownType(calleeType); isUnknown {
+		parameters := make([]ast.Type, len(expr.Arguments))
+
+		for i := range parameters {
+			parameters[i] = tc.freshVariable()
+		}
+
+		function := ast.CreateFunctionType(parameters, tc.freshVariable())
+
+		if !tc.bind(variable, function) {
+			return nil, CreateTypeError(diagnostics.InvalidCallCode, "Cannot call instance of non-function.", expr.Span)
+		}
+
+		calleeType = function
+	}
+
 	switch calleeVariableType := calleeType.(type) {
-	case *ast.VariableType:
+	case *ast.VariableType, *ast.SumType:
 		return nil, CreateTypeError(
+			diagnostics.InvalidCallCode,
 			"Cannot call instance of non-function.",
-			expr.Line,
+			expr.Span,
 		)
 	case *ast.FunctionType:
 		var functionInstance ast.FunctionType = *calleeVariableType
@@ -235,16 +731,29 @@ func (tc *TypeChecker) checkFunctionCall(expr *ast.FunctionCall) (ast.Type, erro
 				len(expr.Arguments),
 			)
 
-			return nil, CreateTypeError(message, expr.Line)
+			return nil, CreateTypeError(diagnostics.InvalidCallCode, message, expr.Span)
 		}
 
-		for i, param := range functionInstance.Parameters {
-			argType, argErr := tc.CheckExpression(expr.Arguments[i])
+		argTypes := make([]ast.Type, len(expr.Arguments))
+
+		for i, argument := range expr.Arguments {
+			argType, argErr := tc.CheckExpression(argument)
 
 			if argErr != nil {
 				return nil, argErr
 			}
 
+			argTypes[i] = argType
+		}
+
+		// the type arguments of generic functions are inferred from the arguments
+		if len(functionInstance.TypeParameters) > 0 {
+			functionInstance = *tc.instantiate(&functionInstance)
+		}
+
+		for i, param := range functionInstance.Parameters {
+			argType := argTypes[i]
+
 			if !tc.match(param, argType) {
 				message := fmt.Sprintf(
 					"Expected type of %d%s argument to be %s, got %s.",
@@ -254,7 +763,7 @@ func (tc *TypeChecker) checkFunctionCall(expr *ast.FunctionCall) (ast.Type, erro
 					argType.String(),
 				)
 
-				return nil, CreateTypeError(message, expr.Line)
+				return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, expr.Span)
 			}
 		}
 
@@ -288,18 +797,25 @@ func (tc *TypeChecker) checkBinary(expr *ast.Binary) (ast.Type, error) {
 			rightType.String(),
 		)
 
-		return nil, CreateTypeError(message, expr.Line)
+		return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, expr.Span)
 	}
 
+	leftType = ast.Prune(leftType)
 	isValid := HasBinaryRule(expr.Operator, leftType.String())
 
+	// operands that are still unknown are limited to the types the operator applies to, i.e. a and b in
+	// fn(a, b) => a + b can be ints, floats or strings
+	if variable, isUnknown := unknownType(leftType); isUnknown && !isValid {
+		isValid = tc.constrain(variable, BinaryOperandTypes(expr.Operator))
+	}
+
 	if !isValid {
 		message := fmt.Sprintf(
 			"Cannot apply operation to type %s.",
 			leftType.String(),
 		)
 
-		return nil, CreateTypeError(message, expr.Line)
+		return nil, CreateTypeError(diagnostics.InvalidOperationCode, message, expr.Span)
 	}
 
 	switch expr.Operator {
@@ -322,6 +838,364 @@ func (tc *TypeChecker) checkBinary(expr *ast.Binary) (ast.Type, error) {
 	return nil, nil
 }
 
+/*
+Checks that the target of an assignment is a variable or record field and
+that the value has the same type as the target. Compound assignments must
+also be a valid binary operation on the target's type.
+*/
+func (tc *TypeChecker) checkAssignment(expr *ast.Assignment) (ast.Type, error) {
+	var targetType ast.Type
+
+	switch target := expr.Target.(type) {
+	case *ast.VariableExpression:
+		targetExists, binding := tc.context.FindBinding(target.Value)
+
+		if !targetExists {
+			return nil, CreateTypeError(
+				diagnostics.UndefinedNameCode,
+				fmt.Sprintf("Cannot assign to undefined variable '%s'.", target.Value),
+				target.Span,
+			)
+		}
+
+		if binding.Invalid {
+			return nil, errInvalidDeclaration
+		}
+
+		if !binding.Mutable {
+			message := fmt.Sprintf("Cannot assign twice to immutable variable '%s'.", target.Value)
+
+			return nil, CreateTypeError(diagnostics.InvalidAssignmentCode, message, expr.Span).
+				WithLabel(binding.Span, "declared as immutable here").
+				WithNote(mutabilityNote(target.Value, binding))
+		}
+
+		targetType = *binding.Type
+	case *ast.GetExpression:
+		if err := tc.checkMutableField(target, expr); err != nil {
+			return nil, err
+		}
+
+		fieldType, fieldErr := tc.checkGetExpression(target)
+
+		if fieldErr != nil {
+			return nil, fieldErr
+		}
+
+		targetType = fieldType
+	case *ast.IndexExpression:
+		if err := tc.checkMutableField(target, expr); err != nil {
+			return nil, err
+		}
+
+		// assigning to a missing key adds it, so the value isn't optional here
+		_, elementType, elementErr := tc.checkIndex(target)
+
+		if elementErr != nil {
+			return nil, elementErr
+		}
+
+		targetType = elementType
+	default:
+		return nil, CreateTypeError(diagnostics.InvalidAssignmentCode, "Invalid assignment target.", expr.Target.GetSpan())
+	}
+
+	if targetType == nil {
+		return nil, CreateTypeError(diagnostics.InvalidAssignmentCode, "Invalid assignment target.", expr.Target.GetSpan())
+	}
+
+	valueType, valueErr := tc.CheckExpression(expr.Value)
+
+	if valueErr != nil {
+		return nil, valueErr
+	}
+
+	if !tc.match(targetType, valueType) {
+		message := fmt.Sprintf(
+			"Invalid type in assignment. Expected %s but got %s.",
+			targetType.String(),
+			valueType.String(),
+		)
+
+		return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, expr.Value.GetSpan()).
+			WithLabel(expr.Target.GetSpan(), fmt.Sprintf("has type %s", targetType.String()))
+	}
+
+	if operation, isCompound := compoundAssignments[expr.Operator]; isCompound {
+		if !HasBinaryRule(operation, targetType.String()) {
+			message := fmt.Sprintf(
+				"Cannot apply '%s' to type %s.",
+				lexer.GetSymbol(expr.Operator),
+				targetType.String(),
+			)
+
+			return nil, CreateTypeError(diagnostics.InvalidOperationCode, message, expr.Span)
+		}
+	}
+
+	expr.Type = targetType
+
+	return targetType, nil
+}
+
+/*
+A field or element can only be assigned to if the variable it is reached
+through is mutable, i.e. 'user.address.city' and 'users[0].name' require
+'user' and 'users' to be mutable.
+*/
+func (tc *TypeChecker) checkMutableField(field ast.Expression, assignment *ast.Assignment) error {
+	parent := field
+
+	for {
+		if get, ok := parent.(*ast.GetExpression); ok {
+			parent = get.Parent
+		} else if index, ok := parent.(*ast.IndexExpression); ok {
+			parent = index.Collection
+		} else if group, ok := parent.(*ast.Group); ok {
+			parent = group.Value
+		} else {
+			break
+		}
+	}
+
+	root, isVariable := parent.(*ast.VariableExpression)
+
+	// fields of temporary values, i.e. 'make().x', belong to no binding
+	if !isVariable {
+		return nil
+	}
+
+	exists, binding := tc.context.FindBinding(root.Value)
+
+	if !exists || binding.Mutable {
+		return nil
+	}
+
+	message := fmt.Sprintf("Cannot assign to element of immutable variable '%s'.", root.Value)
+
+	if get, isField := field.(*ast.GetExpression); isField {
+		message = fmt.Sprintf(
+			"Cannot assign to field '%s' of immutable variable '%s'.",
+			get.Name,
+			root.Value,
+		)
+	}
+
+	return CreateTypeError(diagnostics.InvalidAssignmentCode, message, assignment.Span).
+		WithLabel(binding.Span, "declared as immutable here").
+		WithNote(mutabilityNote(root.Value, binding))
+}
+
+/*
+Suggests how to make an immutable binding mutable, which depends on where it
+was declared.
+*/
+func mutabilityNote(name string, binding *context.Binding) string {
+	switch binding.Kind {
+	case context.ParameterBinding:
+		return fmt.Sprintf("declare the parameter with 'fn(mut %s: ...)' to allow reassignment", name)
+	case context.LoopBinding:
+		return fmt.Sprintf("loop variables cannot be reassigned, copy it with 'let mut copy = %s' instead", name)
+	case context.MatchBinding:
+		return fmt.Sprintf("names bound by a match arm cannot be reassigned, copy it with 'let mut copy = %s' instead", name)
+	}
+
+	return fmt.Sprintf("declare it with 'let mut %s' to allow reassignment", name)
+}
+
+/*
+Every value in an array literal must have the same type as the first. The
+element type of an empty array is unknown until it is assigned.
+*/
+func (tc *TypeChecker) checkArrayLiteral(expr *ast.ArrayLiteral) (ast.Type, error) {
+	// the element type of an empty array is inferred from how it is used
+	var elementType ast.Type = tc.freshVariable()
+
+	for i, value := range expr.Values {
+		valueType, valueErr := tc.CheckExpression(value)
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		if i == 0 {
+			elementType = valueType
+			continue
+		}
+
+		if !tc.match(elementType, valueType) {
+			message := fmt.Sprintf(
+				"Array values must all have the same type. Expected %s but got %s.",
+				elementType.String(),
+				valueType.String(),
+			)
+
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, value.GetSpan()).
+				WithLabel(expr.Values[0].GetSpan(), fmt.Sprintf("first value has type %s", elementType.String()))
+		}
+	}
+
+	arrayType := ast.CreateArrayType(elementType)
+	expr.Type = arrayType
+
+	return arrayType, nil
+}
+
+/*
+Every key in a map literal must have the same hashable type, and every value
+must have the same type.
+*/
+func (tc *TypeChecker) checkMapLiteral(expr *ast.MapLiteral) (ast.Type, error) {
+	// the key and value types of an empty map are inferred from how it is used
+	mapType := ast.CreateMapType(tc.freshVariable(), tc.freshVariable())
+
+	for i, entry := range expr.Entries {
+		keyType, keyErr := tc.CheckExpression(entry.Key)
+
+		if keyErr != nil {
+			return nil, keyErr
+		}
+
+		valueType, valueErr := tc.CheckExpression(entry.Value)
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		if i == 0 {
+			if !IsHashable(keyType.String()) {
+				message := fmt.Sprintf("Cannot use value of type %s as a map key.", keyType.String())
+
+				return nil, CreateTypeError(diagnostics.UnhashableKeyCode, message, entry.Key.GetSpan())
+			}
+
+			mapType = ast.CreateMapType(keyType, valueType)
+
+			continue
+		}
+
+		first := expr.Entries[0]
+
+		if !tc.match(mapType.Key, keyType) {
+			message := fmt.Sprintf(
+				"Map keys must all have the same type. Expected %s but got %s.",
+				mapType.Key.String(),
+				keyType.String(),
+			)
+
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, entry.Key.GetSpan()).
+				WithLabel(first.Key.GetSpan(), fmt.Sprintf("first key has type %s", mapType.Key.String()))
+		}
+
+		if !tc.match(mapType.Value, valueType) {
+			message := fmt.Sprintf(
+				"Map values must all have the same type. Expected %s but got %s.",
+				mapType.Value.String(),
+				valueType.String(),
+			)
+
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, entry.Value.GetSpan()).
+				WithLabel(first.Value.GetSpan(), fmt.Sprintf("first value has type %s", mapType.Value.String()))
+		}
+	}
+
+	expr.Type = mapType
+
+	return mapType, nil
+}
+
+func (tc *TypeChecker) checkTupleLiteral(expr *ast.TupleLiteral) (ast.Type, error) {
+	elements := make([]ast.Type, len(expr.Values))
+
+	for i, value := range expr.Values {
+		valueType, valueErr := tc.CheckExpression(value)
+
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		elements[i] = valueType
+	}
+
+	tupleType := ast.CreateTupleType(elements)
+	expr.Type = tupleType
+
+	return tupleType, nil
+}
+
+/*
+Arrays are indexed by an int and maps by their key type. Looking up a key
+in a map returns an optional since the key may not exist.
+*/
+func (tc *TypeChecker) checkIndexExpression(expr *ast.IndexExpression) (ast.Type, error) {
+	collectionType, elementType, err := tc.checkIndex(expr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, isMap := collectionType.(*ast.MapType); isMap {
+		elementType = ast.MakeOptional(elementType)
+	}
+
+	expr.Type = elementType
+
+	return elementType, nil
+}
+
+/*
+Checks the collection and index of an index expression. Returns the type of
+the collection and the type of the values stored within it.
+*/
+func (tc *TypeChecker) checkIndex(expr *ast.IndexExpression) (ast.Type, ast.Type, error) {
+	collectionType, collectionErr := tc.CheckExpression(expr.Collection)
+
+	if collectionErr != nil {
+		return nil, nil, collectionErr
+	}
+
+	indexType, indexErr := tc.CheckExpression(expr.Index)
+
+	if indexErr != nil {
+		return nil, nil, indexErr
+	}
+
+	// both arrays and maps can be indexed
+	if _, isUnknown := unknownType(collectionType); isUnknown {
+		return nil, nil, ambiguousTypeError(expr.Collection.GetSpan())
+	}
+
+	if mapType, isMap := collectionType.(*ast.MapType); isMap && !mapType.Optional {
+		if !tc.match(mapType.Key, indexType) {
+			message := fmt.Sprintf(
+				"Expected map key to be %s, got %s.",
+				mapType.Key.String(),
+				indexType.String(),
+			)
+
+			return nil, nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, expr.Index.GetSpan())
+		}
+
+		return collectionType, mapType.Value, nil
+	}
+
+	elementType, isIndexable := tc.elementType(collectionType)
+
+	if !isIndexable {
+		message := fmt.Sprintf("Cannot index into value of type %s.", collectionType.String())
+
+		return nil, nil, CreateTypeError(diagnostics.InvalidOperationCode, message, expr.Collection.GetSpan())
+	}
+
+	if !tc.match(ast.CreateTypeFromLiteral(lexer.INT), indexType) {
+		message := fmt.Sprintf("Expected array index to be int, got %s.", indexType.String())
+
+		return nil, nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, expr.Index.GetSpan())
+	}
+
+	return collectionType, elementType, nil
+}
+
 func (tc *TypeChecker) checkUnary(expr *ast.Unary) (ast.Type, error) {
 	valueType, valueErr := tc.CheckExpression(expr.Value)
 
@@ -331,13 +1205,26 @@ func (tc *TypeChecker) checkUnary(expr *ast.Unary) (ast.Type, error) {
 
 	isValid := HasUnaryRule(expr.Operator, valueType.String())
 
+	// !x infers x to be a bool, whereas -x limits x to an int or a float
+	if variable, isUnknown := unknownType(valueType); isUnknown {
+		isValid = tc.constrain(variable, UnaryOperandTypes(expr.Operator))
+
+		if isValid && expr.Operator == lexer.SUB {
+			expr.Type = valueType
+
+			return valueType, nil
+		}
+
+		valueType = ast.Prune(valueType)
+	}
+
 	if !isValid {
 		message := fmt.Sprintf(
 			"Cannot apply operation to type %s.",
 			valueType.String(),
 		)
 
-		return nil, CreateTypeError(message, expr.Line)
+		return nil, CreateTypeError(diagnostics.InvalidOperationCode, message, expr.Span)
 	}
 
 	switch expr.Operator {
@@ -349,7 +1236,7 @@ func (tc *TypeChecker) checkUnary(expr *ast.Unary) (ast.Type, error) {
 				valueType.String(),
 			)
 
-			return nil, CreateTypeError(message, expr.Line)
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, expr.Span)
 		}
 
 		expr.Type = valueType
@@ -364,7 +1251,7 @@ func (tc *TypeChecker) checkUnary(expr *ast.Unary) (ast.Type, error) {
 				valueType.String(),
 			)
 
-			return nil, CreateTypeError(message, expr.Line)
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, expr.Span)
 		}
 
 		expr.Type = valueType
@@ -392,21 +1279,23 @@ func (tc *TypeChecker) checkLogical(expr *ast.Logical) (ast.Type, error) {
 
 	if !tc.match(leftType, boolType) {
 		return nil, CreateTypeError(
+			diagnostics.MismatchedTypesCode,
 			fmt.Sprintf(
 				"Expected the left side of logical statement to be of type bool, got %s.",
 				leftType.String(),
 			),
-			expr.Line,
+			expr.Span,
 		)
 	}
 
 	if !tc.match(rightType, boolType) {
 		return nil, CreateTypeError(
+			diagnostics.MismatchedTypesCode,
 			fmt.Sprintf(
 				"Expected the right side of logical statement to be of type bool, got %s.",
 				rightType.String(),
 			),
-			expr.Line,
+			expr.Span,
 		)
 	}
 
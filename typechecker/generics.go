@@ -0,0 +1,121 @@
+package typechecker
+
+import (
+	"fmt"
+
+	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
+)
+
+/*
+Adds the type parameters of a generic record or function to the current
+scope, where they can be used like any other type.
+*/
+func (tc *TypeChecker) addTypeParameters(names []string, span io.Span) error {
+	for _, name := range names {
+		if !tc.context.AddTypeParameter(name) {
+			message := fmt.Sprintf("Type parameter '%s' is already defined.", name)
+			return CreateTypeError(diagnostics.DuplicateNameCode, message, span)
+		}
+	}
+
+	return nil
+}
+
+/*
+Looks up the record that a type refers to, substituting its type arguments
+if the record is generic, i.e. Box<int> becomes { value: int }.
+*/
+func (tc *TypeChecker) findRecord(target *ast.VariableType) (bool, *ast.RecordType) {
+	isRecord, record := tc.context.FindType(target.Base)
+
+	if !isRecord || len(record.TypeParameters) == 0 {
+		return isRecord, record
+	}
+
+	bindings := make(map[string]ast.Type)
+
+	for i, name := range record.TypeParameters {
+		if i < len(target.Arguments) {
+			bindings[name] = target.Arguments[i]
+		}
+	}
+
+	return true, ast.Substitute(record, bindings).(*ast.RecordType)
+}
+
+/*
+Checks that every generic record is given as many type arguments as it has
+type parameters, and that no other type is given any.
+*/
+func (tc *TypeChecker) checkTypeArguments(target ast.Type) error {
+	switch targetType := target.(type) {
+	case *ast.VariableType:
+		if targetType.SubType != nil {
+			return tc.checkTypeArguments(targetType.SubType)
+		}
+
+		for _, argument := range targetType.Arguments {
+			if err := tc.checkTypeArguments(argument); err != nil {
+				return err
+			}
+		}
+
+		expected := 0
+
+		if isRecord, record := tc.context.FindType(targetType.Base); isRecord {
+			expected = len(record.TypeParameters)
+		}
+
+		if len(targetType.Arguments) != expected {
+			message := fmt.Sprintf(
+				"Type '%s' expects %d type %s, got %d.",
+				targetType.Base,
+				expected,
+				pluralize("argument", expected),
+				len(targetType.Arguments),
+			)
+
+			return CreateTypeError(diagnostics.TypeArgumentsCode, message, targetType.Span)
+		}
+	case *ast.FunctionType:
+		// type parameters can't shadow other types, otherwise <Box>(Box) -> Box would be ambiguous
+		for _, name := range targetType.TypeParameters {
+			if tc.context.TypeExists(name) {
+				message := fmt.Sprintf("Type parameter '%s' is already defined.", name)
+				return CreateTypeError(diagnostics.DuplicateNameCode, message, targetType.Span)
+			}
+		}
+
+		for _, param := range targetType.Parameters {
+			if err := tc.checkTypeArguments(param); err != nil {
+				return err
+			}
+		}
+
+		return tc.checkTypeArguments(targetType.ReturnType)
+	case *ast.MapType:
+		if err := tc.checkTypeArguments(targetType.Key); err != nil {
+			return err
+		}
+
+		return tc.checkTypeArguments(targetType.Value)
+	case *ast.TupleType:
+		for _, element := range targetType.Elements {
+			if err := tc.checkTypeArguments(element); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func pluralize(word string, count int) string {
+	if count == 1 {
+		return word
+	}
+
+	return word + "s"
+}
@@ -0,0 +1,271 @@
+package typechecker
+
+import (
+	"fmt"
+
+	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
+)
+
+/*
+Creates a type variable for a value whose type will be inferred from how it
+is used, i.e. the parameter x in fn(x) => x + 1.
+*/
+func (tc *TypeChecker) freshVariable() *ast.TypeVariable {
+	variable := &ast.TypeVariable{ID: tc.variables, Level: tc.level}
+	tc.variables++
+
+	return variable
+}
+
+/*
+Returns the type variable that a type refers to if it hasn't been inferred yet.
+*/
+func unknownType(target ast.Type) (*ast.TypeVariable, bool) {
+	variable, isVariable := ast.Prune(target).(*ast.TypeVariable)
+
+	return variable, isVariable
+}
+
+/*
+Unifies a type variable which hasn't been inferred yet with another type. A
+type variable cannot contain itself, i.e. 'a can't be unified with ['a].
+*/
+func (tc *TypeChecker) bind(variable *ast.TypeVariable, target ast.Type) bool {
+	target = ast.Prune(target)
+
+	if other, isVariable := target.(*ast.TypeVariable); isVariable {
+		if other.Root() == variable.Root() {
+			return other.Optional == variable.Optional
+		}
+
+		// 'a? is unified with 'b? by unifying 'a with 'b
+		if variable.Optional && other.Optional {
+			return tc.bind(variable.Root(), other.Root())
+		}
+
+		// binding the non-optional variable keeps the optional one intact
+		if variable.Optional {
+			return tc.bind(other, variable)
+		}
+	}
+
+	// 'a? can only be unified with an optional type, which binds 'a to the type inside of it
+	if variable.Optional {
+		if !isOptional(target) {
+			return false
+		}
+
+		return tc.bind(variable.Root(), requiredType(target))
+	}
+
+	if len(variable.Constraint) > 0 && !tc.satisfies(target, variable.Constraint) {
+		return false
+	}
+
+	for _, free := range ast.FreeVariables(target) {
+		if free == variable {
+			return false
+		}
+
+		// the variables within the type can only be generalized once the variable can
+		if free.Level > variable.Level {
+			free.Level = variable.Level
+		}
+	}
+
+	variable.Instance = target
+
+	return true
+}
+
+/*
+Limits the types that a type variable can be inferred to be, i.e. x in -x can
+only be an int or a float. Returns false if none of the types are allowed.
+*/
+func (tc *TypeChecker) constrain(variable *ast.TypeVariable, types []string) bool {
+	// optional values can't be used with any operator
+	if variable.Optional {
+		return false
+	}
+
+	allowed := types
+
+	if len(variable.Constraint) > 0 {
+		allowed = make([]string, 0)
+
+		for _, name := range variable.Constraint {
+			for _, other := range types {
+				if name == other {
+					allowed = append(allowed, name)
+				}
+			}
+		}
+	}
+
+	if len(allowed) == 0 {
+		return false
+	}
+
+	variable.Constraint = allowed
+
+	return true
+}
+
+/*
+Checks that a type is one of the types a constrained type variable can be
+inferred to be. Another type variable takes on the constraint instead.
+*/
+func (tc *TypeChecker) satisfies(target ast.Type, types []string) bool {
+	switch targetType := target.(type) {
+	case *ast.TypeVariable:
+		return tc.constrain(targetType, types)
+	case *ast.VariableType:
+		if targetType.Optional || targetType.IsArray() || len(targetType.Arguments) > 0 {
+			return false
+		}
+
+		for _, name := range types {
+			if targetType.Base == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+/*
+Replaces the type parameters of a generic function with fresh type variables,
+so that each use of the function can be inferred separately.
+*/
+func (tc *TypeChecker) instantiate(function *ast.FunctionType) *ast.FunctionType {
+	bindings := make(map[string]ast.Type)
+
+	for _, name := range function.TypeParameters {
+		variable := tc.freshVariable()
+		variable.Constraint = function.Constraints[name]
+		bindings[name] = variable
+	}
+
+	generic := ast.CreateFunctionType(function.Parameters, function.ReturnType)
+	generic.Optional = function.Optional
+
+	return ast.Substitute(generic, bindings).(*ast.FunctionType)
+}
+
+/*
+Turns the type variables of a function which weren't inferred while checking
+its declaration into type parameters, i.e. let id = fn(x) => x has the type
+<T>(T) -> T. Type variables which are shared with the enclosing scope can still
+be inferred later on, so they are left alone.
+*/
+func (tc *TypeChecker) generalize(function *ast.FunctionType) *ast.FunctionType {
+	typeParameters := append([]string{}, function.TypeParameters...)
+	constraints := make(map[string][]string)
+	taken := make(map[string]bool)
+
+	for name, constraint := range function.Constraints {
+		constraints[name] = constraint
+	}
+
+	// a name used by a nested generic function would be shadowed by it, i.e. <T>(T) -> <T>(T) -> T
+	typeNames(function, taken)
+
+	for _, variable := range ast.FreeVariables(function) {
+		if variable.Level <= tc.level {
+			continue
+		}
+
+		name := tc.typeParameterName(len(typeParameters), taken)
+		taken[name] = true
+		typeParameters = append(typeParameters, name)
+
+		if len(variable.Constraint) > 0 {
+			constraints[name] = variable.Constraint
+		}
+
+		variable.Instance = ast.CreateVariableType(name, false)
+	}
+
+	if len(typeParameters) == len(function.TypeParameters) {
+		return function
+	}
+
+	generic := ast.Resolve(function).(*ast.FunctionType)
+	generic.TypeParameters = typeParameters
+
+	if len(constraints) > 0 {
+		generic.Constraints = constraints
+	}
+
+	return generic
+}
+
+/*
+Adds every type parameter and type name within the type to the set of names.
+*/
+func typeNames(t ast.Type, names map[string]bool) {
+	switch targetType := ast.Prune(t).(type) {
+	case *ast.VariableType:
+		names[targetType.Base] = true
+
+		if targetType.SubType != nil {
+			typeNames(targetType.SubType, names)
+		}
+
+		for _, argument := range targetType.Arguments {
+			typeNames(argument, names)
+		}
+	case *ast.FunctionType:
+		for _, name := range targetType.TypeParameters {
+			names[name] = true
+		}
+
+		for _, param := range targetType.Parameters {
+			typeNames(param, names)
+		}
+
+		typeNames(targetType.ReturnType, names)
+	case *ast.RecordType:
+		for _, field := range targetType.Fields {
+			typeNames(field, names)
+		}
+	case *ast.MapType:
+		typeNames(targetType.Key, names)
+		typeNames(targetType.Value, names)
+	case *ast.TupleType:
+		for _, element := range targetType.Elements {
+			typeNames(element, names)
+		}
+	}
+}
+
+/*
+Picks a name for an inferred type parameter which doesn't refer to another type.
+*/
+func (tc *TypeChecker) typeParameterName(index int, taken map[string]bool) string {
+	names := []string{"T", "U", "V", "W"}
+
+	for i := index; ; i++ {
+		name := names[i%len(names)]
+
+		if i >= len(names) {
+			name += fmt.Sprint(i / len(names))
+		}
+
+		if !taken[name] && !tc.context.TypeExists(name) {
+			return name
+		}
+	}
+}
+
+/*
+Reports a value whose type couldn't be inferred from how it is used, i.e.
+accessing a field of a parameter without a type annotation.
+*/
+func ambiguousTypeError(span io.Span) *TypeError {
+	return CreateTypeError(diagnostics.AmbiguousTypeCode, "Cannot infer the type of this value.", span).
+		WithNote("add a type annotation")
+}
@@ -0,0 +1,302 @@
+package typechecker
+
+import (
+	"fmt"
+
+	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/context"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
+	"github.com/gmisail/glamlang/lexer"
+)
+
+/*
+Adds every name in the pattern to the current scope, checking that the
+shape of the pattern matches the type of the value being destructured.
+*/
+func (tc *TypeChecker) bindPattern(
+	pattern ast.Pattern,
+	patternType ast.Type,
+	mutable bool,
+	kind context.BindingKind,
+) error {
+	patternType = ast.Prune(patternType)
+
+	switch target := pattern.(type) {
+	case *ast.WildcardPattern:
+		return nil
+	case *ast.BindingPattern:
+		// a name which refers to a variant of the value's type matches that variant
+		if sum := tc.findSumType(patternType); sum != nil && sum.FindVariant(target.Name) != nil {
+			return nil
+		}
+
+		// a constructor name never binds a variable, so it can't match a value of another type
+		if isConstructor, sum := tc.context.FindConstructor(target.Name); isConstructor {
+			if variable, isUnknown := unknownType(patternType); isUnknown && tc.bind(variable, sum) {
+				return nil
+			}
+
+			message := fmt.Sprintf(
+				"Cannot match variant '%s' of '%s' against value of type %s.",
+				target.Name,
+				sum.Name,
+				patternType.String(),
+			)
+
+			return CreateTypeError(diagnostics.InvalidPatternCode, message, target.Span)
+		}
+
+		bindingType := patternType
+
+		if !tc.context.Add(target.Name, context.CreateBinding(&bindingType, mutable, kind, target.Span)) {
+			message := fmt.Sprintf("Variable '%s' already in scope.", target.Name)
+			return CreateTypeError(diagnostics.DuplicateNameCode, message, target.Span)
+		}
+
+		return nil
+	case *ast.TuplePattern:
+		// a tuple pattern infers a value of unknown type to be a tuple, i.e. fn(t) => match t { (a, b) => a }
+		if variable, isUnknown := unknownType(patternType); isUnknown {
+			elements := make([]ast.Type, len(target.Elements))
+
+			for i := range elements {
+				elements[i] = tc.freshVariable()
+			}
+
+			if tc.bind(variable, ast.CreateTupleType(elements)) {
+				patternType = ast.Prune(patternType)
+			}
+		}
+
+		tupleType, isTuple := patternType.(*ast.TupleType)
+
+		if !isTuple {
+			message := fmt.Sprintf("Cannot destructure value of type %s as a tuple.", patternType.String())
+			return CreateTypeError(diagnostics.InvalidPatternCode, message, target.Span)
+		}
+
+		if len(tupleType.Elements) != len(target.Elements) {
+			message := fmt.Sprintf(
+				"Expected a tuple with %d elements, but the pattern has %d.",
+				len(tupleType.Elements),
+				len(target.Elements),
+			)
+
+			return CreateTypeError(diagnostics.InvalidPatternCode, message, target.Span)
+		}
+
+		for i, element := range target.Elements {
+			if err := tc.bindPattern(element, tupleType.Elements[i], mutable, kind); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case *ast.RecordPattern:
+		fields, fieldsErr := tc.recordFields(patternType, target.Span)
+
+		if fieldsErr != nil {
+			return fieldsErr
+		}
+
+		return tc.bindFields(target.Fields, fields, patternType.String(), mutable, kind)
+	case *ast.VariantPattern:
+		sum := tc.findSumType(patternType)
+
+		if sum == nil {
+			message := fmt.Sprintf(
+				"Cannot match variant '%s' against value of type %s.",
+				target.Name,
+				patternType.String(),
+			)
+
+			return CreateTypeError(diagnostics.InvalidPatternCode, message, target.Span)
+		}
+
+		variant := sum.FindVariant(target.Name)
+
+		if variant == nil {
+			message := fmt.Sprintf("Variant '%s' does not belong to type '%s'.", target.Name, sum.Name)
+			return CreateTypeError(diagnostics.InvalidPatternCode, message, target.Span)
+		}
+
+		return tc.bindFields(target.Fields, variant.Record.Fields, variant.Name, mutable, kind)
+	case *ast.ArrayPattern:
+		arrayType, isArray := patternType.(*ast.VariableType)
+
+		if !isArray || !arrayType.IsArray() || arrayType.Optional {
+			message := fmt.Sprintf("Cannot match value of type %s against an array pattern.", patternType.String())
+			return CreateTypeError(diagnostics.InvalidPatternCode, message, target.Span)
+		}
+
+		for _, element := range target.Elements {
+			if err := tc.bindPattern(element, arrayType.SubType, mutable, kind); err != nil {
+				return err
+			}
+		}
+
+		if target.Rest != nil {
+			return tc.bindPattern(target.Rest, arrayType, mutable, kind)
+		}
+
+		return nil
+	case *ast.LiteralPattern:
+		literalType := ast.CreateTypeFromLiteral(target.LiteralType)
+
+		// none only matches optional values, whereas other literals match the value inside
+		if target.LiteralType == lexer.NULL {
+			if isOptional(patternType) {
+				return nil
+			}
+		} else if tc.match(requiredType(patternType), literalType) {
+			return nil
+		}
+
+		message := fmt.Sprintf(
+			"Cannot match value of type %s against a literal of type %s.",
+			patternType.String(),
+			literalType.String(),
+		)
+
+		return CreateTypeError(diagnostics.InvalidPatternCode, message, target.Span)
+	}
+
+	return CreateTypeError(diagnostics.InvalidPatternCode, fmt.Sprintf("Unknown pattern: %T", pattern), pattern.GetSpan())
+}
+
+/*
+Returns the fields of a record type, looking up named records in the context.
+*/
+func (tc *TypeChecker) recordFields(recordType ast.Type, span io.Span) (map[string]ast.Type, error) {
+	switch target := recordType.(type) {
+	case *ast.RecordType:
+		return target.Fields, nil
+	case *ast.VariableType:
+		isRecord, record := tc.findRecord(target)
+
+		if isRecord && !target.Optional && !target.IsArray() {
+			return record.Fields, nil
+		}
+	}
+
+	message := fmt.Sprintf("Cannot destructure value of type %s as a record.", recordType.String())
+
+	return nil, CreateTypeError(diagnostics.InvalidPatternCode, message, span)
+}
+
+/*
+Binds the patterns of the given record fields, each of which must exist.
+*/
+func (tc *TypeChecker) bindFields(
+	patterns []ast.RecordPatternField,
+	fields map[string]ast.Type,
+	typeName string,
+	mutable bool,
+	kind context.BindingKind,
+) error {
+	for _, field := range patterns {
+		fieldType, fieldExists := fields[field.Name]
+
+		if !fieldExists {
+			message := fmt.Sprintf(
+				"Member variable '%s' does not exist on type '%s'.",
+				field.Name,
+				typeName,
+			)
+
+			return CreateTypeError(diagnostics.InvalidFieldCode, message, field.Span)
+		}
+
+		if err := tc.bindPattern(field.Pattern, fieldType, mutable, kind); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Returns the sum type that a type refers to, or nil if it isn't a sum type.
+*/
+func (tc *TypeChecker) findSumType(target ast.Type) *ast.SumType {
+	switch sumType := target.(type) {
+	case *ast.SumType:
+		if sumType.Optional {
+			return nil
+		}
+
+		return sumType
+	case *ast.VariableType:
+		if sumType.Optional || sumType.IsArray() {
+			return nil
+		}
+
+		if isSum, sum := tc.context.FindSumType(sumType.Base); isSum {
+			return sum
+		}
+	}
+
+	return nil
+}
+
+func isOptional(target ast.Type) bool {
+	switch optionalType := target.(type) {
+	case *ast.VariableType:
+		return optionalType.Optional
+	case *ast.FunctionType:
+		return optionalType.Optional
+	case *ast.RecordType:
+		return optionalType.Optional
+	case *ast.MapType:
+		return optionalType.Optional
+	case *ast.TupleType:
+		return optionalType.Optional
+	case *ast.SumType:
+		return optionalType.Optional
+	}
+
+	return false
+}
+
+/*
+Returns the type of the value inside an optional, i.e. int? becomes int.
+*/
+func requiredType(target ast.Type) ast.Type {
+	switch optionalType := target.(type) {
+	case *ast.VariableType:
+		required := *optionalType
+		required.Optional = false
+
+		return &required
+	case *ast.FunctionType:
+		required := *optionalType
+		required.Optional = false
+
+		return &required
+	case *ast.RecordType:
+		required := *optionalType
+		required.Optional = false
+
+		return &required
+	case *ast.MapType:
+		required := *optionalType
+		required.Optional = false
+
+		return &required
+	case *ast.TupleType:
+		required := *optionalType
+		required.Optional = false
+
+		return &required
+	case *ast.SumType:
+		required := *optionalType
+		required.Optional = false
+
+		return &required
+	case *ast.TypeVariable:
+		return optionalType.Root()
+	}
+
+	return target
+}
@@ -1,6 +1,8 @@
 package typechecker
 
 import (
+	"sort"
+
 	"github.com/gmisail/glamlang/lexer"
 )
 
@@ -73,6 +75,30 @@ var binaryRules = map[lexer.TokenType]map[string]bool{
 	},
 }
 
+// types that can be converted to a string, i.e. within an interpolated string
+var stringConversionRules = map[string]bool{
+	"int":    true,
+	"float":  true,
+	"bool":   true,
+	"string": true,
+}
+
+// the operation that each compound assignment applies before assigning
+var compoundAssignments = map[lexer.TokenType]lexer.TokenType{
+	lexer.ADD_EQUAL:  lexer.ADD,
+	lexer.SUB_EQUAL:  lexer.SUB,
+	lexer.MULT_EQUAL: lexer.MULT,
+	lexer.DIV_EQUAL:  lexer.DIV,
+}
+
+// types that can be used as the key of a map
+var hashableTypes = map[string]bool{
+	"int":    true,
+	"float":  true,
+	"bool":   true,
+	"string": true,
+}
+
 func HasBinaryRule(operation lexer.TokenType, variableType string) bool {
 	if operation == lexer.EQUALITY || operation == lexer.NOT_EQUAL {
 		return true
@@ -96,3 +122,48 @@ func HasUnaryRule(operation lexer.TokenType, variableType string) bool {
 
 	return false
 }
+
+/*
+Returns the types that a binary operation can be applied to, in alphabetical
+order, i.e. float, int and string for +.
+*/
+func BinaryOperandTypes(operation lexer.TokenType) []string {
+	return ruleTypes(binaryRules[operation])
+}
+
+/*
+Returns the types that a unary operation can be applied to, in alphabetical order.
+*/
+func UnaryOperandTypes(operation lexer.TokenType) []string {
+	return ruleTypes(unaryRules[operation])
+}
+
+func ruleTypes(rules map[string]bool) []string {
+	types := make([]string, 0)
+
+	for variableType, rule := range rules {
+		if rule {
+			types = append(types, variableType)
+		}
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+func IsHashable(variableType string) bool {
+	if rule, ruleOk := hashableTypes[variableType]; ruleOk {
+		return rule
+	}
+
+	return false
+}
+
+func HasStringConversion(variableType string) bool {
+	if rule, ruleOk := stringConversionRules[variableType]; ruleOk {
+		return rule
+	}
+
+	return false
+}
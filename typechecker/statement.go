@@ -4,6 +4,9 @@ import (
 	"fmt"
 
 	"github.com/gmisail/glamlang/ast"
+	"github.com/gmisail/glamlang/context"
+	"github.com/gmisail/glamlang/diagnostics"
+	"github.com/gmisail/glamlang/io"
 	"github.com/gmisail/glamlang/lexer"
 )
 
@@ -18,6 +21,8 @@ func (tc *TypeChecker) CheckStatement(statement ast.Statement) error {
 		return err
 	case *ast.VariableDeclaration:
 		return tc.checkVariableDeclaration(targetStatement)
+	case *ast.DestructuringDeclaration:
+		return tc.checkDestructuringDeclaration(targetStatement)
 	case *ast.BlockStatement:
 		tc.context.EnterScope()
 		// check every statement within a block
@@ -38,19 +43,34 @@ func (tc *TypeChecker) CheckStatement(statement ast.Statement) error {
 		return tc.checkIfStatement(targetStatement)
 	case *ast.WhileStatement:
 		return tc.checkWhileStatement(targetStatement)
+	case *ast.ForStatement:
+		return tc.checkForStatement(targetStatement)
+	case *ast.BreakStatement:
+		return tc.checkJump("break", targetStatement.Label, targetStatement.Span)
+	case *ast.ContinueStatement:
+		return tc.checkJump("continue", targetStatement.Label, targetStatement.Span)
 	case *ast.RecordDeclaration:
 		return tc.checkRecordStatement(targetStatement)
+	case *ast.SumTypeDeclaration:
+		return tc.checkSumTypeStatement(targetStatement)
 	case *ast.ReturnStatement:
 		return tc.checkReturnStatement(targetStatement)
+	case *ast.BadStatement:
+		return nil
 	}
 
 	return CreateTypeError(
+		diagnostics.InvalidDeclarationCode,
 		fmt.Sprintf("Failed to type check unknown statement: %T\n", statement),
-		0,
+		statement.GetSpan(),
 	)
 }
 
 func (tc *TypeChecker) checkVariableDeclaration(v *ast.VariableDeclaration) error {
+	if v.Type == nil {
+		return tc.checkInferredDeclaration(v)
+	}
+
 	/*
 		let x : int = 100
 				 |	   |
@@ -59,15 +79,26 @@ func (tc *TypeChecker) checkVariableDeclaration(v *ast.VariableDeclaration) erro
 	*/
 	variableType := v.Type
 
-	isValidVariable := tc.context.Add(v.Name, &variableType)
+	if keyErr := tc.checkMapKeys(variableType); keyErr != nil {
+		return keyErr
+	}
+
+	if argumentErr := tc.checkTypeArguments(variableType); argumentErr != nil {
+		return argumentErr
+	}
+
+	isValidVariable := tc.context.Add(
+		v.Name,
+		context.CreateBinding(&variableType, v.Mutable, context.VariableBinding, v.Span),
+	)
 
 	if !isValidVariable {
 		message := fmt.Sprintf("Variable '%s' already in scope.", v.Name)
-		return CreateTypeError(message, v.Line)
+		return CreateTypeError(diagnostics.DuplicateNameCode, message, v.Span)
 	}
 
 	if v.Value == nil {
-		return CreateTypeError("Variable declaration cannot have a value of null.", v.Line)
+		return CreateTypeError(diagnostics.InvalidDeclarationCode, "Variable declaration cannot have a value of null.", v.Span)
 	}
 
 	valueType, valueErr := tc.CheckExpression(v.Value)
@@ -85,12 +116,127 @@ func (tc *TypeChecker) checkVariableDeclaration(v *ast.VariableDeclaration) erro
 			valueType.String(),
 		)
 
-		return CreateTypeError(message, v.Line)
+		return CreateTypeError(diagnostics.MismatchedTypesCode, message, v.Value.GetSpan()).
+			WithLabel(variableType.GetSpan(), "expected due to this type")
 	}
 
 	return nil
 }
 
+/*
+Infers the type of a variable declared without a type annotation from its
+value, i.e. let x = 100. Functions are generalized once they have been
+checked, so let id = fn(x) => x can be called with any type.
+*/
+func (tc *TypeChecker) checkInferredDeclaration(v *ast.VariableDeclaration) error {
+	// the type variables created while checking the value are one level deeper
+	tc.level++
+
+	// the variable is in scope within its own value so that functions can be recursive
+	var variableType ast.Type = tc.freshVariable()
+
+	binding := context.CreateBinding(&variableType, v.Mutable, context.VariableBinding, v.Span)
+	isValidVariable := tc.context.Add(v.Name, binding)
+
+	if !isValidVariable {
+		tc.level--
+
+		message := fmt.Sprintf("Variable '%s' already in scope.", v.Name)
+		return CreateTypeError(diagnostics.DuplicateNameCode, message, v.Span)
+	}
+
+	valueType, valueErr := tc.CheckExpression(v.Value)
+
+	if valueErr == nil && !tc.match(variableType, valueType) {
+		message := fmt.Sprintf(
+			"Invalid type in variable declaration. Expected %s but got %s.",
+			ast.Resolve(variableType).String(),
+			valueType.String(),
+		)
+
+		valueErr = CreateTypeError(diagnostics.MismatchedTypesCode, message, v.Value.GetSpan())
+	}
+
+	tc.level--
+
+	// later uses would only report errors caused by the partially inferred type
+	if valueErr != nil {
+		binding.Invalid = true
+
+		return valueErr
+	}
+
+	variableType = ast.Resolve(variableType)
+
+	// only function expressions are generalized, since other values may be shared, i.e. let xs = []
+	if function, isFunction := variableType.(*ast.FunctionType); isFunction {
+		if _, isFunctionExpression := v.Value.(*ast.FunctionExpression); isFunctionExpression {
+			variableType = tc.generalize(function)
+		}
+	}
+
+	return nil
+}
+
+func (tc *TypeChecker) checkDestructuringDeclaration(d *ast.DestructuringDeclaration) error {
+	valueType, valueErr := tc.CheckExpression(d.Value)
+
+	if valueErr != nil {
+		return valueErr
+	}
+
+	// without an annotation the names are bound using the type of the value
+	if d.Type == nil {
+		return tc.bindDeclaration(d, valueType)
+	}
+
+	if keyErr := tc.checkMapKeys(d.Type); keyErr != nil {
+		return keyErr
+	}
+
+	if argumentErr := tc.checkTypeArguments(d.Type); argumentErr != nil {
+		return argumentErr
+	}
+
+	if !tc.match(d.Type, valueType) {
+		message := fmt.Sprintf(
+			"Invalid type in variable declaration. Expected %s but got %s.",
+			d.Type.String(),
+			valueType.String(),
+		)
+
+		return CreateTypeError(diagnostics.MismatchedTypesCode, message, d.Value.GetSpan()).
+			WithLabel(d.Type.GetSpan(), "expected due to this type")
+	}
+
+	return tc.bindDeclaration(d, d.Type)
+}
+
+/*
+Binds the names in a destructuring declaration. Since there is nothing to
+fall back on, the pattern has to match every value of the declared type.
+*/
+func (tc *TypeChecker) bindDeclaration(d *ast.DestructuringDeclaration, declaredType ast.Type) error {
+	if err := tc.bindPattern(d.Pattern, declaredType, d.Mutable, context.VariableBinding); err != nil {
+		return err
+	}
+
+	missing := tc.missingCases([]ast.Pattern{d.Pattern}, declaredType)
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	typeErr := CreateTypeError(diagnostics.NonExhaustiveCode, "Pattern in let declaration may not match every value.", d.Pattern.GetSpan()).
+		WithNote("use a match expression to handle values which don't match")
+
+	for _, pattern := range missing {
+		typeErr.WithNote(fmt.Sprintf("missing case: %s", pattern))
+	}
+
+	return typeErr
+}
+
 /*
 Check if the condition of an if statement is a boolean, and that
 its body type checks properly.
@@ -108,7 +254,7 @@ func (tc *TypeChecker) checkIfStatement(stat *ast.IfStatement) error {
 			conditionType.String(),
 		)
 
-		return CreateTypeError(message, stat.Line)
+		return CreateTypeError(diagnostics.MismatchedTypesCode, message, stat.Span)
 	}
 
 	if statementErr := tc.CheckStatement(stat.Body); statementErr != nil {
@@ -140,9 +286,12 @@ func (tc *TypeChecker) checkWhileStatement(stat *ast.WhileStatement) error {
 			conditionType.String(),
 		)
 
-		return CreateTypeError(message, stat.Line)
+		return CreateTypeError(diagnostics.MismatchedTypesCode, message, stat.Span)
 	}
 
+	tc.enterLoop(stat.Label)
+	defer tc.exitLoop()
+
 	if statementErr := tc.CheckStatement(stat.Body); statementErr != nil {
 		return statementErr
 	}
@@ -150,30 +299,278 @@ func (tc *TypeChecker) checkWhileStatement(stat *ast.WhileStatement) error {
 	return nil
 }
 
+/*
+Check that the loop iterates over a range or collection and that the body
+type checks with the loop variable in scope. The type of the loop variable
+is inferred from the iterable.
+*/
+func (tc *TypeChecker) checkForStatement(stat *ast.ForStatement) error {
+	var variableType ast.Type
+
+	if iterable, isRange := stat.Iterable.(*ast.RangeExpression); isRange {
+		rangeType, rangeErr := tc.checkRange(iterable)
+
+		if rangeErr != nil {
+			return rangeErr
+		}
+
+		variableType = rangeType
+	} else {
+		iterableType, iterableErr := tc.CheckExpression(stat.Iterable)
+
+		if iterableErr != nil {
+			return iterableErr
+		}
+
+		if _, isUnknown := unknownType(iterableType); isUnknown {
+			return ambiguousTypeError(stat.Iterable.GetSpan())
+		}
+
+		elementType, isIterable := tc.elementType(iterableType)
+
+		if !isIterable {
+			message := fmt.Sprintf("Cannot iterate over value of type %s.", iterableType.String())
+
+			return CreateTypeError(diagnostics.InvalidOperationCode, message, stat.Iterable.GetSpan())
+		}
+
+		variableType = elementType
+	}
+
+	tc.context.EnterScope()
+	defer tc.context.ExitScope()
+
+	if !tc.context.Add(stat.Variable, context.CreateBinding(&variableType, false, context.LoopBinding, stat.VariableSpan)) {
+		message := fmt.Sprintf("Variable '%s' already in scope.", stat.Variable)
+		return CreateTypeError(diagnostics.DuplicateNameCode, message, stat.VariableSpan)
+	}
+
+	tc.enterLoop(stat.Label)
+	defer tc.exitLoop()
+
+	return tc.CheckStatement(stat.Body)
+}
+
+func (tc *TypeChecker) enterLoop(label string) {
+	tc.loops = append(tc.loops, label)
+}
+
+func (tc *TypeChecker) exitLoop() {
+	tc.loops = tc.loops[:len(tc.loops)-1]
+}
+
+/*
+Check that a 'break' or 'continue' is within a loop and, if it has a label,
+that one of the enclosing loops has that label.
+*/
+func (tc *TypeChecker) checkJump(keyword string, label string, span io.Span) error {
+	if len(tc.loops) == 0 {
+		return CreateTypeError(diagnostics.InvalidJumpCode, fmt.Sprintf("Cannot use '%s' outside of a loop.", keyword), span)
+	}
+
+	if label == "" {
+		return nil
+	}
+
+	for _, loop := range tc.loops {
+		if loop == label {
+			return nil
+		}
+	}
+
+	return CreateTypeError(diagnostics.InvalidJumpCode, fmt.Sprintf("Undefined loop label '%s'.", label), span)
+}
+
+/*
+Both bounds of a range must be integers. Returns the type of each value
+in the range.
+*/
+func (tc *TypeChecker) checkRange(expr *ast.RangeExpression) (ast.Type, error) {
+	intType := ast.CreateTypeFromLiteral(lexer.INT)
+
+	for _, bound := range []ast.Expression{expr.Start, expr.End} {
+		boundType, boundErr := tc.CheckExpression(bound)
+
+		if boundErr != nil {
+			return nil, boundErr
+		}
+
+		if !tc.match(intType, boundType) {
+			message := fmt.Sprintf("Expected bounds of range to be int, got %s.", boundType.String())
+
+			return nil, CreateTypeError(diagnostics.MismatchedTypesCode, message, bound.GetSpan())
+		}
+	}
+
+	return intType, nil
+}
+
+/*
+Returns the type of each value produced when iterating over a collection
+of the given type.
+*/
+func (tc *TypeChecker) elementType(collectionType ast.Type) (ast.Type, bool) {
+	if arrayType, ok := collectionType.(*ast.VariableType); ok && arrayType.IsArray() && !arrayType.Optional {
+		return arrayType.SubType, true
+	}
+
+	return nil, false
+}
+
+/*
+Check that a named type, or the element type of an array, is either a
+primitive or has been declared.
+*/
+func (tc *TypeChecker) checkTypeExists(target ast.Type) error {
+	if mapType, isMap := target.(*ast.MapType); isMap {
+		if keyErr := tc.checkMapKeys(mapType); keyErr != nil {
+			return keyErr
+		}
+
+		if err := tc.checkTypeExists(mapType.Key); err != nil {
+			return err
+		}
+
+		return tc.checkTypeExists(mapType.Value)
+	}
+
+	if tupleType, isTuple := target.(*ast.TupleType); isTuple {
+		for _, element := range tupleType.Elements {
+			if err := tc.checkTypeExists(element); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	variableType, ok := target.(*ast.VariableType)
+
+	if !ok {
+		return nil
+	}
+
+	if variableType.IsArray() {
+		return tc.checkTypeExists(variableType.SubType)
+	}
+
+	for _, argument := range variableType.Arguments {
+		if err := tc.checkTypeExists(argument); err != nil {
+			return err
+		}
+	}
+
+	if !tc.context.TypeExists(variableType.Base) {
+		isPrimitive, _ := ast.IsInternalType(variableType)
+
+		if !isPrimitive {
+			message := fmt.Sprintf(
+				"Type '%s' does not exist in this context.",
+				variableType.String(),
+			)
+
+			return CreateTypeError(diagnostics.UndefinedNameCode, message, variableType.GetSpan())
+		}
+	}
+
+	return nil
+}
+
+/*
+Check that every map within a type annotation has a hashable key, i.e.
+{[int]: string} is rejected.
+*/
+func (tc *TypeChecker) checkMapKeys(target ast.Type) error {
+	switch targetType := target.(type) {
+	case *ast.MapType:
+		if !IsHashable(targetType.Key.String()) {
+			message := fmt.Sprintf("Cannot use type %s as a map key.", targetType.Key.String())
+
+			return CreateTypeError(diagnostics.UnhashableKeyCode, message, targetType.Key.GetSpan()).
+				WithNote("map keys must be int, float, bool or string")
+		}
+
+		return tc.checkMapKeys(targetType.Value)
+	case *ast.VariableType:
+		if targetType.SubType != nil {
+			return tc.checkMapKeys(targetType.SubType)
+		}
+	case *ast.FunctionType:
+		for _, param := range targetType.Parameters {
+			if err := tc.checkMapKeys(param); err != nil {
+				return err
+			}
+		}
+
+		return tc.checkMapKeys(targetType.ReturnType)
+	case *ast.TupleType:
+		for _, element := range targetType.Elements {
+			if err := tc.checkMapKeys(element); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (tc *TypeChecker) checkRecordStatement(stat *ast.RecordDeclaration) error {
-	isDefined, _ := tc.context.FindType(stat.Name)
-	fields := make(map[string]ast.Type)
+	if err := checkTypeName(stat.Name, stat.Span); err != nil {
+		return err
+	}
+
+	isDefined := tc.context.TypeExists(stat.Name)
 
 	if isDefined {
 		message := fmt.Sprintf("Record '%s' already defined.", stat.String())
-		return CreateTypeError(message, stat.Line)
+		return CreateTypeError(diagnostics.DuplicateNameCode, message, stat.Span)
+	}
+
+	// type parameters are only in scope within the record
+	tc.context.EnterScope()
+	fields, fieldsErr := tc.checkRecordFields(stat)
+	tc.context.ExitScope()
+
+	if fieldsErr != nil {
+		return fieldsErr
+	}
+
+	tc.context.AddType(stat.Name, ast.RecordType{TypeParameters: stat.TypeParameters, Fields: fields})
+
+	return nil
+}
+
+/*
+Built-in types can't be redeclared, i.e. type int { x: int } would make every
+int a record.
+*/
+func checkTypeName(name string, span io.Span) error {
+	isInternal, _ := ast.IsInternalType(ast.CreateVariableType(name, false))
+
+	if isInternal || name == ast.ArrayBase {
+		message := fmt.Sprintf("Cannot declare type '%s', since it is a built-in type.", name)
+		return CreateTypeError(diagnostics.InvalidDeclarationCode, message, span)
+	}
+
+	return nil
+}
+
+func (tc *TypeChecker) checkRecordFields(stat *ast.RecordDeclaration) (map[string]ast.Type, error) {
+	fields := make(map[string]ast.Type)
+
+	if err := tc.addTypeParameters(stat.TypeParameters, stat.Span); err != nil {
+		return nil, err
 	}
 
 	for variableName, variableType := range stat.Record.Fields {
 		switch innerType := variableType.(type) {
-		case *ast.VariableType:
-			if !tc.context.TypeExists(innerType.Base) {
-				isPrimitive, _ := ast.IsInternalType(variableType)
-
-				if !isPrimitive {
-					message := fmt.Sprintf(
-						"Type '%s' does not exist in this context.",
-						variableType.String(),
-					)
-
-					// TODO: update to use line number from the type field
-					return CreateTypeError(message, 0)
-				}
+		case *ast.VariableType, *ast.MapType, *ast.TupleType:
+			if err := tc.checkTypeExists(innerType); err != nil {
+				return nil, err
+			}
+
+			if err := tc.checkTypeArguments(innerType); err != nil {
+				return nil, err
 			}
 		case *ast.FunctionType:
 			continue
@@ -184,14 +581,69 @@ func (tc *TypeChecker) checkRecordStatement(stat *ast.RecordDeclaration) error {
 		fields[variableName] = variableType
 	}
 
-	tc.context.AddType(stat.Name, ast.RecordType{Fields: fields})
+	return fields, nil
+}
+
+/*
+Registers a sum type along with a constructor for each of its variants. The
+sum type is added before its fields are checked so that it may be recursive,
+i.e. type List = Cons { head: int, tail: List } | Nil
+*/
+func (tc *TypeChecker) checkSumTypeStatement(stat *ast.SumTypeDeclaration) error {
+	sum := stat.Sum
+
+	if err := checkTypeName(sum.Name, stat.Span); err != nil {
+		return err
+	}
+
+	if tc.context.TypeExists(sum.Name) {
+		message := fmt.Sprintf("Type '%s' already defined.", sum.Name)
+		return CreateTypeError(diagnostics.DuplicateNameCode, message, stat.Span)
+	}
+
+	declared := make(map[string]*ast.Variant)
+
+	for _, variant := range sum.Variants {
+		if previous, isDuplicate := declared[variant.Name]; isDuplicate {
+			message := fmt.Sprintf("Variant '%s' is declared more than once in '%s'.", variant.Name, sum.Name)
+
+			return CreateTypeError(diagnostics.DuplicateNameCode, message, variant.Span).
+				WithLabel(previous.Span, "first declared here")
+		}
+
+		if exists, other := tc.context.FindConstructor(variant.Name); exists {
+			message := fmt.Sprintf("Constructor '%s' is already defined by '%s'.", variant.Name, other.Name)
+
+			return CreateTypeError(diagnostics.DuplicateNameCode, message, variant.Span)
+		}
+
+		declared[variant.Name] = variant
+	}
+
+	tc.context.AddSumType(sum.Name, sum)
+
+	for _, variant := range sum.Variants {
+		for _, fieldType := range variant.Record.Fields {
+			if err := tc.checkTypeExists(fieldType); err != nil {
+				return err
+			}
+
+			if err := tc.checkTypeArguments(fieldType); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, variant := range sum.Variants {
+		tc.context.AddConstructor(variant.Name, sum)
+	}
 
 	return nil
 }
 
 func (tc *TypeChecker) checkReturnStatement(stat *ast.ReturnStatement) error {
 	if stat.Value == nil {
-		return CreateTypeError("Return statement must have value.", stat.Line)
+		return CreateTypeError(diagnostics.MissingReturnCode, "Return statement must have value.", stat.Span)
 	}
 
 	returnType, err := tc.CheckExpression(stat.Value)
@@ -248,8 +700,9 @@ func (tc *TypeChecker) checkLastReturnStatement(
 	case *ast.BlockStatement:
 		if len(stat.Statements) <= 0 {
 			return false, CreateTypeError(
+				diagnostics.MissingReturnCode,
 				"Body does not have a return statement.",
-				stat.Line,
+				stat.Span,
 			)
 		}
 
@@ -260,12 +713,13 @@ func (tc *TypeChecker) checkLastReturnStatement(
 
 			if !tc.match(expectedType, returnType) {
 				return false, CreateTypeError(
+					diagnostics.MismatchedTypesCode,
 					fmt.Sprintf(
 						"Expected function to return value of type %s, but instead returned %s.",
 						expectedType.String(),
 						returnType.String(),
 					),
-					statement.Line,
+					statement.Span,
 				)
 			}
 
@@ -273,8 +727,9 @@ func (tc *TypeChecker) checkLastReturnStatement(
 		}
 
 		return false, CreateTypeError(
+			diagnostics.MissingReturnCode,
 			"The last statement of a function body must be a return statement.",
-			stat.Line,
+			stat.Span,
 		)
 	case *ast.ExpressionStatement:
 		expressionType, err := tc.CheckExpression(stat.Value)
@@ -283,14 +738,15 @@ func (tc *TypeChecker) checkLastReturnStatement(
 			return false, err
 		}
 
-		if !tc.match(expressionType, expressionType) {
+		if !tc.match(expectedType, expressionType) {
 			return false, CreateTypeError(
+				diagnostics.MismatchedTypesCode,
 				fmt.Sprintf(
 					"Expected function to return value of type %s, but instead returned %s.",
 					expectedType.String(),
 					expressionType.String(),
 				),
-				stat.Line,
+				stat.Span,
 			)
 		}
 
@@ -298,8 +754,9 @@ func (tc *TypeChecker) checkLastReturnStatement(
 	}
 
 	return false, CreateTypeError(
+		diagnostics.MissingReturnCode,
 		"Checking for return statement on invalid statement.",
-		0,
+		body.GetSpan(),
 	)
 }
 
@@ -317,15 +774,17 @@ func (tc *TypeChecker) checkStatementForReturns(
 
 		if returnType == nil {
 			return CreateTypeError(
+				diagnostics.MismatchedTypesCode,
 				"Invalid return type.",
-				statementType.Value.GetLine(),
+				statementType.Value.GetSpan(),
 			)
 		}
 
 		if !tc.match(expectedType, returnType) {
 			return CreateTypeError(
+				diagnostics.MismatchedTypesCode,
 				"Expected incorrect return type.",
-				statementType.Value.GetLine(),
+				statementType.Value.GetSpan(),
 			)
 		}
 	case *ast.IfStatement:
@@ -342,6 +801,10 @@ func (tc *TypeChecker) checkStatementForReturns(
 		if err := tc.checkStatementForReturns(expectedType, statementType.Body); err != nil {
 			return err
 		}
+	case *ast.ForStatement:
+		if err := tc.checkStatementForReturns(expectedType, statementType.Body); err != nil {
+			return err
+		}
 	case *ast.BlockStatement:
 		if err := tc.checkAllReturnStatements(expectedType, statementType); err != nil {
 			return err
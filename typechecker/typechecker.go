@@ -1,31 +1,48 @@
 package typechecker
 
 import (
-	//	"fmt"
-	"github.com/fatih/color"
 	"github.com/gmisail/glamlang/ast"
 	"github.com/gmisail/glamlang/context"
 )
 
 type TypeChecker struct {
-	context *context.Context
+	context  *context.Context
+	Errors   []*TypeError
+	Warnings []*TypeError
+
+	// labels of the loops enclosing the current statement, innermost last
+	loops []string
+
+	// number of type variables created so far, and how many let declarations
+	// enclose the current expression. Type variables created at a deeper level
+	// than the declaration can be generalized once it has been checked.
+	variables int
+	level     int
 }
 
 func CreateTypeChecker() *TypeChecker {
-	return &TypeChecker{context: context.CreateContext()}
+	return &TypeChecker{
+		context:  context.CreateContext(),
+		Errors:   make([]*TypeError, 0),
+		Warnings: make([]*TypeError, 0),
+	}
 }
 
 func (tc *TypeChecker) CheckAll(statements []ast.Statement) bool {
-	isValid := true
-
 	for _, s := range statements {
 		err := tc.CheckStatement(s)
 
-		if err != nil {
-			color.Red(err.Error())
-			//fmt.Printf("INVALID: %s\n", s.String())
+		if typeErr, ok := err.(*TypeError); ok {
+			tc.Errors = append(tc.Errors, typeErr)
 		}
 	}
 
-	return isValid
+	return len(tc.Errors) == 0
+}
+
+/*
+Records a warning, which unlike an error does not stop checking.
+*/
+func (tc *TypeChecker) warn(warning *TypeError) {
+	tc.Warnings = append(tc.Warnings, warning)
 }